@@ -13,6 +13,6 @@ const (
 )
 
 func main() {
-	diffs := diffmatchpatch.Diff(text1, text2, false)
-	fmt.Println(diffmatchpatch.DiffPretty(diffs))
+	diffs := diffmatchpatch.DiffMain(text1, text2, false)
+	fmt.Println(diffmatchpatch.DiffPrettyText(diffs))
 }
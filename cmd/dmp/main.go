@@ -0,0 +1,385 @@
+// Command dmp diffs, patches and searches texts using diffmatchpatch.
+//
+// Usage:
+//
+//	dmp diff [-format text|html|side-html|term|side|delta|patch|unified] [-color auto|never|16|256] [-width n] [-lines] [-algorithm name] file1 file2
+//	dmp patch [-margin n] [-timeout d] file1 file2
+//	dmp apply [-o out] [-strict] patchfile target
+//	dmp match [-loc n] [-threshold t] [-distance d] file pattern
+//
+// A file name of "-" reads standard input.
+//
+// As with diff(1), the exit status is 0 on success, 1 when the texts differ,
+// a patch did not apply cleanly or a pattern was not found, and 2 on error.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kenshaw/diffmatchpatch"
+)
+
+// Exit statuses.
+const (
+	exitOK      = 0
+	exitFailed  = 1
+	exitTrouble = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// command is a subcommand, run with the parsed flag set and its arguments.
+type command struct {
+	usage string
+	flags func(*flag.FlagSet, *diffmatchpatch.Config) func(*env, []string) (int, error)
+	nargs int
+}
+
+var commands = map[string]command{
+	"diff": {
+		"diff [flags] file1 file2",
+		diffFlags,
+		2,
+	},
+	"patch": {
+		"patch [flags] file1 file2",
+		patchFlags,
+		2,
+	},
+	"apply": {
+		"apply [flags] patchfile target",
+		applyFlags,
+		2,
+	},
+	"match": {
+		"match [flags] file pattern",
+		matchFlags,
+		2,
+	},
+}
+
+// env holds the streams and config of a run.
+type env struct {
+	config *diffmatchpatch.Config
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// run runs the command line args, returning the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitTrouble
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+			usage(stdout)
+			return exitOK
+		}
+		fmt.Fprintf(stderr, "dmp: unknown command %q\n", args[0])
+		usage(stderr)
+		return exitTrouble
+	}
+	config := diffmatchpatch.NewDefaultConfig()
+	fs := flag.NewFlagSet("dmp "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: dmp %s\n", cmd.usage)
+		fs.PrintDefaults()
+	}
+	f := cmd.flags(fs, config)
+	rest, err := parseInterspersed(fs, args[1:])
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case err != nil:
+		return exitTrouble
+	case len(rest) != cmd.nargs:
+		fs.Usage()
+		return exitTrouble
+	}
+	status, err := f(&env{config, stdin, stdout, stderr}, rest)
+	if err != nil {
+		fmt.Fprintf(stderr, "dmp %s: %v\n", args[0], err)
+		return exitTrouble
+	}
+	return status
+}
+
+// usage writes the list of commands to w.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: dmp <command> [flags] args...")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, name := range []string{"diff", "patch", "apply", "match"} {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+}
+
+// parseInterspersed parses flags in args, allowing them to follow the
+// positional arguments, which are returned.  "--" ends the flags.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		n := len(args) - fs.NArg()
+		if n != 0 && args[n-1] == "--" {
+			return append(rest, fs.Args()...), nil
+		}
+		if fs.NArg() == 0 {
+			return rest, nil
+		}
+		rest = append(rest, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// diffFlags sets up the flags of the diff command.
+func diffFlags(fs *flag.FlagSet, config *diffmatchpatch.Config) func(*env, []string) (int, error) {
+	format := fs.String("format", "text", "output `format`: text, html, side-html, term, side, delta, patch or unified")
+	color := fs.String("color", "auto", "terminal colors for the term and side formats: auto, never, 16 or 256")
+	width := fs.Int("width", 0, "terminal `width` for the side format (default $COLUMNS or 80)")
+	lines := fs.Bool("lines", false, "speed up large diffs with a line-level first pass")
+	algorithm := fs.String("algorithm", "myers", "diff `algorithm`: myers, patience or histogram")
+	fs.DurationVar(&config.DiffTimeout, "timeout", config.DiffTimeout, "give up refining the diff after `duration` (0 for no limit)")
+	return func(e *env, args []string) (int, error) {
+		switch *algorithm {
+		case "myers":
+			config.DiffAlgorithm = diffmatchpatch.AlgorithmMyers
+		case "patience":
+			config.DiffAlgorithm = diffmatchpatch.AlgorithmPatience
+		case "histogram":
+			config.DiffAlgorithm = diffmatchpatch.AlgorithmHistogram
+		default:
+			return 0, fmt.Errorf("unknown algorithm %q", *algorithm)
+		}
+		text1, err := e.read(args[0])
+		if err != nil {
+			return 0, err
+		}
+		text2, err := e.read(args[1])
+		if err != nil {
+			return 0, err
+		}
+		var out string
+		switch *format {
+		case "text":
+			out = config.DiffPrettyText(e.diff(text1, text2, *lines))
+		case "html":
+			out = config.DiffPrettyHtml(e.diff(text1, text2, *lines))
+		case "side-html":
+			out = config.DiffSideBySideHtml(e.diff(text1, text2, *lines), true)
+		case "term", "side":
+			opts, err := e.termOptions(*color, *width)
+			if err != nil {
+				return 0, err
+			}
+			if *format == "term" {
+				out = config.DiffTermUnified(e.diff(text1, text2, *lines), opts)
+			} else {
+				out = config.DiffTermSideBySide(e.diff(text1, text2, *lines), opts)
+			}
+		case "delta":
+			out = config.DiffToDelta(e.diff(text1, text2, *lines))
+		case "patch":
+			out = config.PatchToText(config.PatchMakeFromDiffs(e.diff(text1, text2, *lines)))
+		case "unified":
+			out = config.DiffUnified(args[0], args[1], text1, text2)
+		default:
+			return 0, fmt.Errorf("unknown format %q", *format)
+		}
+		if _, err := io.WriteString(e.stdout, out); err != nil {
+			return 0, err
+		}
+		if out != "" && !strings.HasSuffix(out, "\n") && *format != "patch" && *format != "unified" && *format != "side-html" {
+			fmt.Fprintln(e.stdout)
+		}
+		if text1 != text2 {
+			return exitFailed, nil
+		}
+		return exitOK, nil
+	}
+}
+
+// termOptions returns the options of the terminal formats.  Colors are only
+// used by default when writing to a terminal.
+func (e *env) termOptions(color string, width int) (diffmatchpatch.TermOptions, error) {
+	opts := diffmatchpatch.TermOptions{Width: width}
+	if opts.Width <= 0 {
+		opts.Width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	}
+	switch color {
+	case "never":
+	case "16":
+		opts.Color = diffmatchpatch.TermColor16
+	case "256":
+		opts.Color = diffmatchpatch.TermColor256
+	case "auto":
+		f, ok := e.stdout.(*os.File)
+		if !ok {
+			break
+		}
+		if fi, err := f.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+			break
+		}
+		switch term := os.Getenv("TERM"); {
+		case term == "" || term == "dumb" || os.Getenv("NO_COLOR") != "":
+		case strings.Contains(term, "256color"):
+			opts.Color = diffmatchpatch.TermColor256
+		default:
+			opts.Color = diffmatchpatch.TermColor16
+		}
+	default:
+		return opts, fmt.Errorf("unknown color mode %q", color)
+	}
+	return opts, nil
+}
+
+// diff diffs two texts, cleaning the diffs up for people to read.
+func (e *env) diff(text1, text2 string, lines bool) []diffmatchpatch.Diff {
+	return e.config.DiffCleanupSemantic(e.config.Diff(text1, text2, lines))
+}
+
+// patchFlags sets up the flags of the patch command.
+func patchFlags(fs *flag.FlagSet, config *diffmatchpatch.Config) func(*env, []string) (int, error) {
+	fs.IntVar(&config.PatchMargin, "margin", config.PatchMargin, "grow the context of hunks by `bytes` at a time, until unique")
+	fs.DurationVar(&config.DiffTimeout, "timeout", config.DiffTimeout, "give up refining the diff after `duration` (0 for no limit)")
+	return func(e *env, args []string) (int, error) {
+		text1, err := e.read(args[0])
+		if err != nil {
+			return 0, err
+		}
+		text2, err := e.read(args[1])
+		if err != nil {
+			return 0, err
+		}
+		if _, err := io.WriteString(e.stdout, config.PatchToText(config.PatchMakeFromTexts(text1, text2))); err != nil {
+			return 0, err
+		}
+		if text1 != text2 {
+			return exitFailed, nil
+		}
+		return exitOK, nil
+	}
+}
+
+// applyFlags sets up the flags of the apply command.
+func applyFlags(fs *flag.FlagSet, config *diffmatchpatch.Config) func(*env, []string) (int, error) {
+	out := fs.String("o", "-", "write the patched text to `file`")
+	strict := fs.Bool("strict", false, "apply hunks only where expected and exactly matching, or not at all")
+	fs.Float64Var(&config.MatchThreshold, "threshold", config.MatchThreshold, "how loosely hunks may match (0.0 exact, 1.0 anything)")
+	fs.IntVar(&config.MatchDistance, "distance", config.MatchDistance, "how far in bytes hunks may move")
+	return func(e *env, args []string) (int, error) {
+		patchText, err := e.read(args[0])
+		if err != nil {
+			return 0, err
+		}
+		text, err := e.read(args[1])
+		if err != nil {
+			return 0, err
+		}
+		patches, err := parsePatches(config, patchText, text)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %v", args[0], err)
+		}
+		var patched string
+		status := exitOK
+		if *strict {
+			if patched, err = config.PatchApplyStrict(patches, text); err != nil {
+				fmt.Fprintf(e.stderr, "dmp apply: %v\n", err)
+				return exitFailed, nil
+			}
+		} else {
+			var reports []diffmatchpatch.PatchReport
+			patched, reports = config.PatchApplyReport(patches, text)
+			for i, r := range reports {
+				result := "applied"
+				switch {
+				case !r.Applied:
+					result, status = "FAILED", exitFailed
+				case !r.Exact:
+					result = fmt.Sprintf("applied with fuzz %.2f", r.Score)
+				}
+				if r.Loc != -1 && r.Loc != r.ExpectedLoc {
+					result += fmt.Sprintf(" (offset %d)", r.Loc-r.ExpectedLoc)
+				}
+				header := patches[i].String()
+				if n := strings.IndexByte(header, '\n'); n != -1 {
+					header = header[:n]
+				}
+				fmt.Fprintf(e.stderr, "hunk #%d %s %s\n", i+1, header, result)
+			}
+		}
+		if *out == "-" {
+			_, err = io.WriteString(e.stdout, patched)
+		} else {
+			err = ioutil.WriteFile(*out, []byte(patched), 0o644)
+		}
+		if err != nil {
+			return 0, err
+		}
+		return status, nil
+	}
+}
+
+// parsePatches parses patches in the format of PatchToText, or a unified
+// diff of a single file to be applied to text.
+func parsePatches(config *diffmatchpatch.Config, patchText, text string) ([]diffmatchpatch.Patch, error) {
+	if strings.HasPrefix(patchText, "@@ -") {
+		return config.PatchFromText(patchText)
+	}
+	files, err := config.UnifiedFromText(patchText)
+	switch {
+	case err != nil:
+		return nil, err
+	case len(files) == 0:
+		return nil, errors.New("no patches found")
+	case len(files) > 1:
+		return nil, fmt.Errorf("patches %d files, expected 1", len(files))
+	}
+	return config.PatchFromUnified(text, files[0]), nil
+}
+
+// matchFlags sets up the flags of the match command.
+func matchFlags(fs *flag.FlagSet, config *diffmatchpatch.Config) func(*env, []string) (int, error) {
+	loc := fs.Int("loc", 0, "expected `offset` of the pattern, in bytes")
+	fs.Float64Var(&config.MatchThreshold, "threshold", config.MatchThreshold, "how loosely the pattern may match (0.0 exact, 1.0 anything)")
+	fs.IntVar(&config.MatchDistance, "distance", config.MatchDistance, "how far in bytes the match may be from -loc")
+	return func(e *env, args []string) (int, error) {
+		text, err := e.read(args[0])
+		if err != nil {
+			return 0, err
+		}
+		i := config.Match(text, args[1], *loc)
+		if i == -1 {
+			return exitFailed, nil
+		}
+		fmt.Fprintln(e.stdout, i)
+		return exitOK, nil
+	}
+}
+
+// read reads the named file, or standard input for "-".
+func (e *env) read(name string) (string, error) {
+	var buf []byte
+	var err error
+	if name == "-" {
+		buf, err = ioutil.ReadAll(e.stdin)
+	} else {
+		buf, err = ioutil.ReadFile(name)
+	}
+	return string(buf), err
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt":     "Lorem ipsum dolor.\n",
+		"b.txt":     "Lorem dolor sit amet.\n",
+		"p.txt":     "@@ -3,17 +3,20 @@\n rem \n-ipsum dolor\n+dolor sit amet\n .%0A\n",
+		"bad.txt":   "@@ -1,3 +1,3 @@\n-xyz\n+abc\n",
+		"u.diff":    "--- a.txt\n+++ b.txt\n@@ -1 +1 @@\n-Lorem ipsum dolor.\n+Lorem dolor sit amet.\n",
+		"junk.txt":  "@@ junk\n",
+		"other.txt": "Something else entirely.\n",
+		"c.txt":     "Well, Lorem ipsum dolor!\n",
+	}
+	for name, text := range files {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(text), 0o644))
+	}
+	path := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+	tests := []struct {
+		Name   string
+		Args   []string
+		Stdin  string
+		Status int
+		Stdout string
+		Stderr string
+	}{
+		{"No command", nil, "", 2, "", "usage: dmp"},
+		{"Unknown command", []string{"frob"}, "", 2, "", `unknown command "frob"`},
+		{"Help", []string{"help"}, "", 0, "usage: dmp", ""},
+		{"Diff equal", []string{"diff", path("a.txt"), path("a.txt")}, "", 0, "Lorem ipsum dolor.\n", ""},
+		{
+			"Diff delta",
+			[]string{"diff", "-format", "delta", path("a.txt"), path("b.txt")},
+			"", 1, "=6\t-11\t+dolor sit amet\t=2\n", "",
+		},
+		{
+			"Diff patch with trailing flag",
+			[]string{"diff", path("a.txt"), path("b.txt"), "--format", "patch"},
+			"", 1, files["p.txt"], "",
+		},
+		{
+			"Diff html from stdin",
+			[]string{"diff", "-format=html", "-", path("b.txt")},
+			"Lorem dolor sit amet.\n", 0, "<span>Lorem dolor sit amet.&para;<br></span>\n", "",
+		},
+		{
+			"Diff term",
+			[]string{"diff", "-format", "term", path("a.txt"), path("b.txt")},
+			"", 1, "@@ -1 +1 @@\n-Lorem ipsum dolor.\n+Lorem dolor sit amet.\n", "",
+		},
+		{
+			"Diff side with colors",
+			[]string{"diff", "-format", "side", "-color", "16", "-width", "60", path("a.txt"), path("b.txt")},
+			"", 1, "1 \x1b[31mLorem \x1b[0m\x1b[7;31mipsum dolor\x1b[0m\x1b[31m.", "",
+		},
+		{
+			"Diff side html",
+			[]string{"diff", "-format", "side-html", path("a.txt"), path("b.txt")},
+			"", 1, `<td class="dmp-delete">Lorem <del>ipsum dolor</del>.</td>`, "",
+		},
+		{"Diff unknown color mode", []string{"diff", "-format", "term", "-color", "red", path("a.txt"), path("b.txt")}, "", 2, "", `unknown color mode "red"`},
+		{"Diff unknown format", []string{"diff", "-format", "xml", path("a.txt"), path("b.txt")}, "", 2, "", `unknown format "xml"`},
+		{"Diff unknown algorithm", []string{"diff", "-algorithm", "fast", path("a.txt"), path("b.txt")}, "", 2, "", `unknown algorithm "fast"`},
+		{"Diff missing file", []string{"diff", path("a.txt"), path("missing.txt")}, "", 2, "", "missing.txt"},
+		{"Diff wrong arguments", []string{"diff", path("a.txt")}, "", 2, "", "usage: dmp diff"},
+		{"Diff bad flag", []string{"diff", "-frob", path("a.txt"), path("b.txt")}, "", 2, "", "flag provided but not defined"},
+		{"Patch", []string{"patch", path("a.txt"), path("b.txt")}, "", 1, files["p.txt"], ""},
+		{"Patch equal", []string{"patch", path("a.txt"), path("a.txt")}, "", 0, "", ""},
+		{
+			"Patch with margin",
+			[]string{"patch", "-margin", "1", path("a.txt"), path("b.txt")},
+			"", 1, "@@ -6,13 +6,16 @@\n  \n-ipsum dolor\n+dolor sit amet\n .\n", "",
+		},
+		{"Patch wrong arguments", []string{"patch", path("a.txt")}, "", 2, "", "usage: dmp patch"},
+		{
+			"Apply",
+			[]string{"apply", path("p.txt"), path("a.txt")},
+			"", 0, files["b.txt"], "hunk #1 @@ -3,17 +3,20 @@ applied\n",
+		},
+		{
+			"Apply unified",
+			[]string{"apply", path("u.diff"), path("a.txt")},
+			"", 0, files["b.txt"], "applied\n",
+		},
+		{
+			"Apply failed hunk",
+			[]string{"apply", path("bad.txt"), path("a.txt")},
+			"", 1, files["a.txt"], "hunk #1 @@ -1,3 +1,3 @@ FAILED\n",
+		},
+		{
+			"Apply fuzzy",
+			[]string{"apply", path("p.txt"), path("c.txt")},
+			"", 0, "Well, Lorem dolor sit amet!\n", "hunk #1 @@ -3,17 +3,20 @@ applied with fuzz 0.05 (offset 6)\n",
+		},
+		{"Apply strict", []string{"apply", "-strict", path("p.txt"), path("a.txt")}, "", 0, files["b.txt"], ""},
+		{
+			"Apply strict mismatch",
+			[]string{"apply", "-strict", path("p.txt"), path("c.txt")},
+			"", 1, "", `does not match text at 2: expected "rem ipsum dolor.\n"`,
+		},
+		{"Apply invalid patch", []string{"apply", path("junk.txt"), path("a.txt")}, "", 2, "", "junk.txt"},
+		{"Apply no patches", []string{"apply", path("other.txt"), path("a.txt")}, "", 2, "", "no patches found"},
+		{"Match", []string{"match", path("b.txt"), "sit", "--loc", "3"}, "", 0, "12\n", ""},
+		{"Match fuzzy", []string{"match", "-loc", "6", path("b.txt"), "dolar"}, "", 0, "6\n", ""},
+		{"Match after --", []string{"match", "--", path("b.txt"), "-loc"}, "", 1, "", ""},
+		{"No match", []string{"match", path("b.txt"), "zzzzz"}, "", 1, "", ""},
+	}
+	for i, test := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(test.Args, strings.NewReader(test.Stdin), &stdout, &stderr)
+		msg := fmt.Sprintf("Test case #%d, %s", i, test.Name)
+		assert.Equal(t, test.Status, status, msg)
+		if test.Stdout == "" {
+			assert.Equal(t, "", stdout.String(), msg)
+		} else {
+			assert.Contains(t, stdout.String(), test.Stdout, msg)
+		}
+		assert.Contains(t, stderr.String(), test.Stderr, msg)
+	}
+
+	// Write the patched text to a file.
+	out := path("out.txt")
+	assert.Equal(t, 0, run([]string{"apply", "-o", out, path("p.txt"), path("a.txt")}, nil, ioutil.Discard, ioutil.Discard))
+	buf, err := ioutil.ReadFile(out)
+	assert.Nil(t, err)
+	assert.Equal(t, files["b.txt"], string(buf))
+}
@@ -0,0 +1,350 @@
+package diffmatchpatch
+
+import (
+	"fmt"
+	"math"
+)
+
+// PatchCompose composes patches from text A to text B with patches from B to
+// C, returning patches from A to C without needing text B.  Patches must be
+// in order and must not overlap, as made by PatchMake.  Returns an error if
+// the patches conflict, such as when p2 expects different text where p1
+// inserted text.
+//
+// The context of the returned patches is the context of p1 and p2 that falls
+// in text A.
+func (config *Config) PatchCompose(p1, p2 []Patch) ([]Patch, error) {
+	ops1, err := patchOps(p1)
+	if err != nil {
+		return nil, fmt.Errorf("p1: %v", err)
+	}
+	ops2, err := patchOps(p2)
+	if err != nil {
+		return nil, fmt.Errorf("p2: %v", err)
+	}
+	ops, err := composeOps(ops1, ops2)
+	if err != nil {
+		return nil, err
+	}
+	return config.opsPatches(ops), nil
+}
+
+// PatchTransform transforms patches a over patches b, where both were made
+// from the same text, returning patches a' to apply after b such that
+// applying b then a' gives the same text as applying a then b', where b' is
+// PatchTransform(b, a).  Insertions at the same place are ordered by their
+// text, so that a' and b' agree.  Returns an error if a and b do not agree
+// on the text they were made from.
+func (config *Config) PatchTransform(a, b []Patch) ([]Patch, error) {
+	opsA, err := patchOps(a)
+	if err != nil {
+		return nil, fmt.Errorf("a: %v", err)
+	}
+	opsB, err := patchOps(b)
+	if err != nil {
+		return nil, fmt.Errorf("b: %v", err)
+	}
+	ops, err := transformOps(opsA, opsB)
+	if err != nil {
+		return nil, err
+	}
+	return config.opsPatches(ops), nil
+}
+
+// patchOp is an operation on a text: an equality retains N bytes, which are
+// Text if known, a deletion deletes Text, and an insertion inserts Text.
+type patchOp struct {
+	Op   Op
+	N    int
+	Text string
+}
+
+// known returns whether the text of op is known.
+func (op patchOp) known() bool {
+	return op.Op != OpEqual || len(op.Text) == op.N
+}
+
+// split splits op after n bytes.
+func (op patchOp) split(n int) (patchOp, patchOp) {
+	if !op.known() {
+		return patchOp{OpEqual, n, ""}, patchOp{OpEqual, op.N - n, ""}
+	}
+	return patchOp{op.Op, n, op.Text[:n]}, patchOp{op.Op, op.N - n, op.Text[n:]}
+}
+
+// appendOp appends op to ops, joining it with the last op where possible.
+func appendOp(ops []patchOp, op patchOp) []patchOp {
+	if op.N == 0 {
+		return ops
+	}
+	if n := len(ops); n != 0 && ops[n-1].Op == op.Op && ops[n-1].known() == op.known() {
+		last := &ops[n-1]
+		last.N += op.N
+		last.Text += op.Text
+		return ops
+	}
+	return append(ops, op)
+}
+
+// patchOps converts patches to the ops they perform on the text they were
+// made from, up to the end of the context of the last patch.
+//
+// The context of a patch can overlap the changes of the patches next to it,
+// so only the text between the changes of consecutive patches is known to
+// be unchanged; the contexts give what they can of it.
+func patchOps(patches []Patch) ([]patchOp, error) {
+	var ops []patchOp
+	// The end of the changes of the last patch in the patched text, and the
+	// context following them.
+	end, trail := 0, ""
+	for i, p := range patches {
+		// Split the patch into its leading context, changes and trailing
+		// context.
+		first, last := 0, len(p.Diffs)
+		for first < last && p.Diffs[first].Op == OpEqual {
+			first++
+		}
+		for last > first && p.Diffs[last-1].Op == OpEqual {
+			last--
+		}
+		if first == last {
+			continue
+		}
+		lead := diffsText(p.Diffs[:first])
+		// As patches are in order, Start2 is the position of the patch in
+		// the patched text.
+		start := p.Start2 + len(lead)
+		gap := start - end
+		if gap < 0 {
+			return nil, fmt.Errorf("patch %d overlaps the patch before it", i)
+		}
+		ops = appendGap(ops, gap, trail, lead)
+		for _, d := range p.Diffs[first:last] {
+			ops = appendOp(ops, patchOp{d.Op, len(d.Text), d.Text})
+			if d.Op != OpDelete {
+				start += len(d.Text)
+			}
+		}
+		end, trail = start, diffsText(p.Diffs[last:])
+	}
+	return appendGap(ops, len(trail), trail, ""), nil
+}
+
+// appendGap appends an equality of n bytes to ops, of which the first bytes
+// are known to be prefix, and the last bytes suffix.
+func appendGap(ops []patchOp, n int, prefix, suffix string) []patchOp {
+	prefix = prefix[:min(len(prefix), n)]
+	suffix = suffix[max(len(suffix)-n, 0):]
+	if len(prefix)+len(suffix) >= n {
+		return appendOp(ops, patchOp{OpEqual, n, prefix + suffix[len(prefix)+len(suffix)-n:]})
+	}
+	ops = appendOp(ops, patchOp{OpEqual, len(prefix), prefix})
+	ops = appendOp(ops, patchOp{OpEqual, n - len(prefix) - len(suffix), ""})
+	return appendOp(ops, patchOp{OpEqual, len(suffix), suffix})
+}
+
+// diffsText returns the text of diffs, all of which are equalities.
+func diffsText(diffs []Diff) string {
+	var text string
+	for _, d := range diffs {
+		text += d.Text
+	}
+	return text
+}
+
+// composeOps composes ops1, from text A to B, with ops2, from B to C.
+// Both are followed by equalities of unknown text to the end of the text.
+func composeOps(ops1, ops2 []patchOp) ([]patchOp, error) {
+	rest := patchOp{OpEqual, math.MaxInt, ""}
+	var ops []patchOp
+	var a, b patchOp
+	i, j := 0, 0
+	for {
+		if a.N == 0 && i < len(ops1) {
+			a, i = ops1[i], i+1
+		}
+		if b.N == 0 && j < len(ops2) {
+			b, j = ops2[j], j+1
+		}
+		if a.N == 0 && b.N == 0 {
+			return ops, nil
+		}
+		// Past the end of either ops, the text is retained.
+		restA, restB := a.N == 0, b.N == 0
+		if restA {
+			a = rest
+		}
+		if restB {
+			b = rest
+		}
+		// Deletions from A and insertions into C pass through.
+		if a.Op == OpDelete {
+			ops, a = appendOp(ops, a), patchOp{}
+			continue
+		}
+		if b.Op == OpInsert {
+			ops, b = appendOp(ops, b), patchOp{}
+			continue
+		}
+		// Otherwise a produces text of B which b consumes.
+		n := min(a.N, b.N)
+		var x, y patchOp
+		x, a = a.split(n)
+		y, b = b.split(n)
+		if x.known() && y.known() && x.Text != y.Text {
+			return nil, fmt.Errorf("patches conflict: p1 gives %q where p2 expects %q", x.Text, y.Text)
+		}
+		switch {
+		case x.Op == OpEqual && y.Op == OpEqual:
+			if !x.known() {
+				x.Text = y.Text
+			}
+			ops = appendOp(ops, x)
+		case x.Op == OpEqual && y.Op == OpDelete:
+			ops = appendOp(ops, y)
+		case x.Op == OpInsert && y.Op == OpEqual:
+			ops = appendOp(ops, x)
+		default:
+			// An insertion deleted again leaves nothing.
+		}
+		if restA {
+			a = patchOp{}
+		}
+		if restB {
+			b = patchOp{}
+		}
+	}
+}
+
+// transformOps transforms opsA over opsB, where both apply to the same
+// text.  Both are followed by equalities of unknown text to the end of the
+// text.
+func transformOps(opsA, opsB []patchOp) ([]patchOp, error) {
+	rest := patchOp{OpEqual, math.MaxInt, ""}
+	var ops []patchOp
+	var a, b patchOp
+	i, j := 0, 0
+	for {
+		if a.N == 0 && i < len(opsA) {
+			a, i = opsA[i], i+1
+		}
+		if b.N == 0 && j < len(opsB) {
+			b, j = opsB[j], j+1
+		}
+		if a.N == 0 && b.N == 0 {
+			return ops, nil
+		}
+		// Past the end of either ops, the text is retained.
+		restA, restB := a.N == 0, b.N == 0
+		if restA {
+			a = rest
+		}
+		if restB {
+			b = rest
+		}
+		// Insertions go first, ordered by their text when both insert.
+		if a.Op == OpInsert && (b.Op != OpInsert || a.Text <= b.Text) {
+			ops, a = appendOp(ops, a), patchOp{}
+			continue
+		}
+		if b.Op == OpInsert {
+			ops, b = appendOp(ops, patchOp{OpEqual, b.N, b.Text}), patchOp{}
+			continue
+		}
+		// Otherwise both consume the text.
+		n := min(a.N, b.N)
+		var x, y patchOp
+		x, a = a.split(n)
+		y, b = b.split(n)
+		if x.known() && y.known() && x.Text != y.Text {
+			return nil, fmt.Errorf("patches conflict: a expects %q where b expects %q", x.Text, y.Text)
+		}
+		switch {
+		case x.Op == OpEqual && y.Op == OpEqual:
+			if !x.known() {
+				x.Text = y.Text
+			}
+			ops = appendOp(ops, x)
+		case x.Op == OpDelete && y.Op == OpEqual:
+			ops = appendOp(ops, x)
+		default:
+			// Text deleted by b is already gone.
+		}
+		if restA {
+			a = patchOp{}
+		}
+		if restB {
+			b = patchOp{}
+		}
+	}
+}
+
+// opsPatches makes patches from ops, using the known equalities around each
+// change as its context.
+func (config *Config) opsPatches(ops []patchOp) []Patch {
+	patches := []Patch{}
+	var patch Patch
+	open := false
+	// The position in the text the ops apply to, and the change in length
+	// made by the patches so far.
+	pos, shift := 0, 0
+	finish := func() {
+		open = false
+		// Clean up the changes without their context, so that changes are not
+		// slid over it: a patch must start and end with its context.
+		first, last := 0, len(patch.Diffs)
+		for first < last && patch.Diffs[first].Op == OpEqual {
+			first++
+		}
+		for last > first && patch.Diffs[last-1].Op == OpEqual {
+			last--
+		}
+		changes := config.DiffCleanupMerge(append([]Diff(nil), patch.Diffs[first:last]...))
+		changes = diffAppend(nil, config.DiffCleanupEfficiency(changes)...)
+		changed := false
+		for _, d := range changes {
+			changed = changed || d.Op != OpEqual
+		}
+		if !changed {
+			return
+		}
+		diffs := diffAppend(nil, patch.Diffs[:first]...)
+		diffs = diffAppend(diffs, changes...)
+		diffs = diffAppend(diffs, patch.Diffs[last:]...)
+		patch.Diffs = diffs
+		patch.Start1 += shift
+		patch.Start2 = patch.Start1
+		patch.Length1 = len(config.DiffText1(diffs))
+		patch.Length2 = len(config.DiffText2(diffs))
+		shift += patch.Length2 - patch.Length1
+		patches = append(patches, patch)
+	}
+	for k, op := range ops {
+		switch {
+		case op.Op != OpEqual:
+			if !open {
+				open = true
+				patch = Patch{Start1: pos}
+				if k > 0 && ops[k-1].Op == OpEqual && ops[k-1].known() {
+					patch.Diffs = []Diff{{OpEqual, ops[k-1].Text}}
+					patch.Start1 -= ops[k-1].N
+				}
+			}
+			patch.Diffs = append(patch.Diffs, Diff{op.Op, op.Text})
+		case open && op.known():
+			patch.Diffs = append(patch.Diffs, Diff{OpEqual, op.Text})
+			if op.N > 2*config.PatchMargin || k == len(ops)-1 || ops[k+1].Op == OpEqual {
+				finish()
+			}
+		case open:
+			finish()
+		}
+		if op.Op != OpInsert {
+			pos += op.N
+		}
+	}
+	if open {
+		finish()
+	}
+	return patches
+}
@@ -0,0 +1,150 @@
+package diffmatchpatch
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatchCompose(t *testing.T) {
+	tests := []struct {
+		Name     string
+		TextA    string
+		TextB    string
+		TextC    string
+		Expected string
+	}{
+		{"Empty", "", "", "", ""},
+		{"Nothing changed", "Hello world.", "Hello world.", "Hello world.", ""},
+		{"First only", "Hello world.", "Hello there world.", "Hello there world.", "@@ -1,12 +1,18 @@\n Hello \n+there \n world.\n"},
+		{"Second only", "Hello world.", "Hello world.", "Hello world!", "@@ -8,5 +8,5 @@\n orld\n-.\n+!\n"},
+		{
+			"Chained",
+			"The quick brown fox jumps over the lazy dog.",
+			"That quick brown fox jumped over a lazy dog.",
+			"That quick red fox jumped over a lazy cat.",
+			"@@ -1,19 +1,18 @@\n Th\n-e\n+at\n  quick \n-brown\n+red\n  fox\n@@ -20,24 +20,23 @@\n jump\n-s\n+ed\n  over \n-the\n+a\n  lazy \n-dog\n+cat\n .\n",
+		},
+		{"Insertion deleted again", "abcdefghij", "abcdeXYZfghij", "abcdefghij", ""},
+		{"Change reverted", "The quick brown fox.", "The slow brown fox.", "The quick brown fox.", ""},
+		{"Unicode", "日本語のテキスト", "日本のテキストです", "日本のテキスト。", "@@ -1,24 +1,21 @@\n %E6%97%A5%E6%9C%AC\n-%E8%AA%9E\n %E3%81%AE%E3%83%86%E3%82%AD%E3%82%B9%E3%83%88\n@@ -7,15 +7,18 @@\n %E3%81%AE%E3%83%86%E3%82%AD%E3%82%B9%E3%83%88\n+%E3%80%82\n"},
+		{
+			"Changes next to the context",
+			"aaabbaabbbabbabbaaabaababaaababbbbbbaabbbbaaabbbabbb",
+			"aaabbaabbbabbabbaaabaababaaabababbbbbbaabbbbaaabbb",
+			"aaabbbabbaaaabbaaabaababaaabababbbbbbaabbbbaaabbb",
+			"@@ -2,17 +2,16 @@\n aabb\n-aabb\n babba\n+aaa\n bbaa\n@@ -23,16 +23,18 @@\n abaaabab\n+ab\n bbbbbaab\n@@ -42,12 +42,8 @@\n bbaaabbb\n-abbb\n",
+		},
+		{
+			"Far apart",
+			strings.Repeat("abcdefghij", 10),
+			"X" + strings.Repeat("abcdefghij", 10),
+			"X" + strings.Repeat("abcdefghij", 10) + "Y",
+			"@@ -1,28 +1,29 @@\n+X\n abcdefghijabcdefghijabcdefgh\n@@ -74,28 +74,29 @@\n cdefghijabcdefghijabcdefghij\n+Y\n",
+		},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		msg := fmt.Sprintf("Test case #%d, %s", i, test.Name)
+		p1 := config.PatchMakeFromTexts(test.TextA, test.TextB)
+		p2 := config.PatchMakeFromTexts(test.TextB, test.TextC)
+		patches, err := config.PatchCompose(p1, p2)
+		assert.Nil(t, err, msg)
+		assert.Equal(t, test.Expected, config.PatchToText(patches), msg)
+		actual, err := config.PatchApplyStrict(patches, test.TextA)
+		assert.Nil(t, err, msg)
+		assert.Equal(t, test.TextC, actual, msg)
+		actual, applies := config.PatchApply(patches, test.TextA)
+		assert.Equal(t, test.TextC, actual, msg)
+		for _, ok := range applies {
+			assert.True(t, ok, msg)
+		}
+	}
+}
+
+func TestPatchComposeChain(t *testing.T) {
+	config := NewDefaultConfig()
+	texts := []string{
+		"The quick brown fox jumps over the lazy dog.",
+		"The quick brown fox jumps over the lazy dog. It barks.",
+		"A quick brown fox jumps over the lazy dog. It barks.",
+		"A quick brown fox leaps over the lazy dog. It barks loudly.",
+		"A quick brown fox leaps over the dog. It barks loudly!",
+	}
+	patches := []Patch{}
+	for i := 1; i < len(texts); i++ {
+		var err error
+		patches, err = config.PatchCompose(patches, config.PatchMakeFromTexts(texts[i-1], texts[i]))
+		assert.Nil(t, err)
+	}
+	actual, err := config.PatchApplyStrict(patches, texts[0])
+	assert.Nil(t, err)
+	assert.Equal(t, texts[len(texts)-1], actual)
+	actual, _ = config.PatchApply(patches, texts[0])
+	assert.Equal(t, texts[len(texts)-1], actual)
+}
+
+func TestPatchComposeErrors(t *testing.T) {
+	config := NewDefaultConfig()
+	p1 := config.PatchMakeFromTexts("The quick brown fox.", "The slow brown fox.")
+	p2 := config.PatchMakeFromTexts("The fast brown fox.", "The fast red fox.")
+	_, err := config.PatchCompose(p1, p2)
+	assert.EqualError(t, err, `patches conflict: p1 gives "low" where p2 expects "ast"`)
+
+	overlapping := []Patch{
+		{Start1: 0, Start2: 0, Length1: 5, Length2: 5, Diffs: []Diff{{OpEqual, "ab"}, {OpDelete, "c"}, {OpInsert, "C"}, {OpEqual, "de"}}},
+		{Start1: 1, Start2: 1, Length1: 3, Length2: 3, Diffs: []Diff{{OpEqual, "b"}, {OpDelete, "C"}, {OpInsert, "x"}, {OpEqual, "d"}}},
+	}
+	_, err = config.PatchCompose(overlapping, nil)
+	assert.EqualError(t, err, "p1: patch 1 overlaps the patch before it")
+	_, err = config.PatchCompose(nil, overlapping)
+	assert.EqualError(t, err, "p2: patch 1 overlaps the patch before it")
+}
+
+func TestPatchTransform(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Base     string
+		TextA    string
+		TextB    string
+		Expected string
+	}{
+		{"Empty", "", "", "", ""},
+		{"No changes", "Hello world.", "Hello world.", "Hello world.", "Hello world."},
+		{"Only a", "Hello world.", "Hello there world.", "Hello world.", "Hello there world."},
+		{"Only b", "Hello world.", "Hello world.", "Hello world!", "Hello world!"},
+		{"Separate changes", "The quick brown fox.", "The slow brown fox.", "The quick brown cat.", "The slow brown cat."},
+		{"Insertions at the same place", "ab", "aXb", "aYb", "aXYb"},
+		{"Same insertion", "ab", "aXb", "aXb", "aXXb"},
+		{"Same deletion", "abcdef", "abef", "abef", "abef"},
+		{"Insertion into deletion", "abcdef", "abef", "abcXdef", "abXef"},
+		{"Overlapping deletions", "abcdefgh", "abefgh", "abcdgh", "abgh"},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		msg := fmt.Sprintf("Test case #%d, %s", i, test.Name)
+		a := config.PatchMakeFromTexts(test.Base, test.TextA)
+		b := config.PatchMakeFromTexts(test.Base, test.TextB)
+		a2, err := config.PatchTransform(a, b)
+		assert.Nil(t, err, msg)
+		b2, err := config.PatchTransform(b, a)
+		assert.Nil(t, err, msg)
+		actual, err := config.PatchApplyStrict(a2, test.TextB)
+		assert.Nil(t, err, msg)
+		assert.Equal(t, test.Expected, actual, msg)
+		actual, err = config.PatchApplyStrict(b2, test.TextA)
+		assert.Nil(t, err, msg)
+		assert.Equal(t, test.Expected, actual, msg)
+		actual, _ = config.PatchApply(a2, test.TextB)
+		assert.Equal(t, test.Expected, actual, msg)
+		actual, _ = config.PatchApply(b2, test.TextA)
+		assert.Equal(t, test.Expected, actual, msg)
+	}
+
+	a := config.PatchMakeFromTexts("The quick brown fox.", "The slow brown fox.")
+	b := config.PatchMakeFromTexts("The fast brown fox.", "The fast red fox.")
+	_, err := config.PatchTransform(a, b)
+	assert.EqualError(t, err, `patches conflict: a expects "uick" where b expects "ast "`)
+}
@@ -3,10 +3,12 @@ package diffmatchpatch
 //go:generate stringer -type=Op -trimprefix=Op
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"html"
+	"io"
 	"net/url"
 	"regexp"
 	"strconv"
@@ -28,6 +30,22 @@ const (
 	OpEqual Op = 0
 )
 
+// Algorithm is a diff algorithm.
+type Algorithm int
+
+// Algorithm values.
+const (
+	// AlgorithmMyers is Myers' bisection algorithm, with the usual
+	// diff-match-patch speedups.
+	AlgorithmMyers Algorithm = iota
+	// AlgorithmPatience anchors the diff on lines that are unique in both
+	// texts, as git diff --patience does.
+	AlgorithmPatience
+	// AlgorithmHistogram splits the diff on the least common lines shared by
+	// both texts, as git diff --histogram does.
+	AlgorithmHistogram
+)
+
 // Diff contains information about a single diff operation.
 type Diff struct {
 	Op   Op
@@ -42,19 +60,54 @@ func (config *Config) Diff(text1, text2 string, checklines bool) []Diff {
 	return config.DiffRunes([]rune(text1), []rune(text2), checklines)
 }
 
+// DiffContext finds the differences between two texts, stopping early and
+// returning ctx.Err() when ctx is done.
+//
+// If an invalid UTF-8 sequence is encountered, it will be replaced by the
+// Unicode replacement character.
+func (config *Config) DiffContext(ctx context.Context, text1, text2 string, checklines bool) ([]Diff, error) {
+	return config.DiffRunesContext(ctx, []rune(text1), []rune(text2), checklines)
+}
+
 // DiffRunes finds the differences between two rune sequences.
 //
 // If an invalid UTF-8 sequence is encountered, it will be replaced by the
 // Unicode replacement character.
 func (config *Config) DiffRunes(text1, text2 []rune, checklines bool) []Diff {
+	diffs, _ := config.DiffRunesContext(context.Background(), text1, text2, checklines)
+	return diffs
+}
+
+// DiffRunesContext finds the differences between two rune sequences,
+// stopping early and returning ctx.Err() when ctx is done.
+//
+// If an invalid UTF-8 sequence is encountered, it will be replaced by the
+// Unicode replacement character.
+func (config *Config) DiffRunesContext(ctx context.Context, text1, text2 []rune, checklines bool) ([]Diff, error) {
+	deadline := config.diffDeadline()
+	var diffs []Diff
+	if config.DiffAlgorithm == AlgorithmMyers {
+		diffs = config.diffRunes(ctx, text1, text2, checklines, deadline)
+	} else {
+		diffs = config.diffByLines(ctx, text1, text2, deadline)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return diffs, nil
+}
+
+// diffDeadline returns the time at which a diff started now should give up,
+// or the zero time if there is no timeout.
+func (config *Config) diffDeadline() time.Time {
 	var deadline time.Time
 	if config.DiffTimeout > 0 {
 		deadline = time.Now().Add(config.DiffTimeout)
 	}
-	return config.diffRunes(text1, text2, checklines, deadline)
+	return deadline
 }
 
-func (config *Config) diffRunes(text1, text2 []rune, checklines bool, deadline time.Time) []Diff {
+func (config *Config) diffRunes(ctx context.Context, text1, text2 []rune, checklines bool, deadline time.Time) []Diff {
 	if runesEqual(text1, text2) {
 		var diffs []Diff
 		if len(text1) > 0 {
@@ -73,7 +126,7 @@ func (config *Config) diffRunes(text1, text2 []rune, checklines bool, deadline t
 	text1 = text1[:len(text1)-commonlength]
 	text2 = text2[:len(text2)-commonlength]
 	// Compute the diff on the middle block.
-	diffs := config.diffCompute(text1, text2, checklines, deadline)
+	diffs := config.diffCompute(ctx, text1, text2, checklines, deadline)
 	// Restore the prefix and suffix.
 	if len(commonprefix) != 0 {
 		diffs = append([]Diff{{OpEqual, string(commonprefix)}}, diffs...)
@@ -87,7 +140,7 @@ func (config *Config) diffRunes(text1, text2 []rune, checklines bool, deadline t
 // diffCompute finds the differences between two rune slices.
 //
 // Assumes that the texts do not have any common prefix or suffix.
-func (config *Config) diffCompute(text1, text2 []rune, checklines bool, deadline time.Time) []Diff {
+func (config *Config) diffCompute(ctx context.Context, text1, text2 []rune, checklines bool, deadline time.Time) []Diff {
 	diffs := []Diff{}
 	if len(text1) == 0 {
 		// Just add some text (speedup).
@@ -132,30 +185,55 @@ func (config *Config) diffCompute(text1, text2 []rune, checklines bool, deadline
 		text2B := hm[3]
 		midCommon := hm[4]
 		// Send both pairs off for separate processing.
-		diffsA := config.diffRunes(text1A, text2A, checklines, deadline)
-		diffsB := config.diffRunes(text1B, text2B, checklines, deadline)
+		diffsA := config.diffRunes(ctx, text1A, text2A, checklines, deadline)
+		diffsB := config.diffRunes(ctx, text1B, text2B, checklines, deadline)
 		// Merge the results.
 		diffs := diffsA
 		diffs = append(diffs, Diff{OpEqual, string(midCommon)})
 		diffs = append(diffs, diffsB...)
 		return diffs
 	} else if checklines && len(text1) > 100 && len(text2) > 100 {
-		return config.diffLineMode(text1, text2, deadline)
+		return config.diffLineMode(ctx, text1, text2, deadline)
 	}
-	return config.diffBisect(text1, text2, deadline)
+	return config.diffBisect(ctx, text1, text2, deadline)
 }
 
 // diffLineMode does a quick line-level diff on both []runes, then rediff the
 // parts for greater accuracy. This speedup can produce non-minimal diffs.
-func (config *Config) diffLineMode(text1, text2 []rune, deadline time.Time) []Diff {
+func (config *Config) diffLineMode(ctx context.Context, text1, text2 []rune, deadline time.Time) []Diff {
 	// Scan the text on a line-by-line basis first.
 	text1, text2, linearray := config.DiffLinesToRunes(string(text1), string(text2))
-	diffs := config.diffRunes(text1, text2, false, deadline)
+	diffs := config.diffRunes(ctx, text1, text2, false, deadline)
 	// Convert the diff back to original text.
 	diffs = config.DiffCharsToLines(diffs, linearray)
 	// Eliminate freak matches (e.g. blank lines)
 	diffs = config.DiffCleanupSemantic(diffs)
-	// Rediff any replacement blocks, this time character-by-character.
+	return config.diffRediff(ctx, diffs, deadline)
+}
+
+// diffByLines diffs two texts line by line using DiffAlgorithm, then rediffs
+// the changed blocks character by character.
+func (config *Config) diffByLines(ctx context.Context, text1, text2 []rune, deadline time.Time) []Diff {
+	runes1, runes2, lines := linesToRunes(string(text1), string(text2))
+	diffs := runesToLines(config.diffLines(ctx, runes1, runes2, deadline), lines)
+	return diffAppend(nil, config.diffRediff(ctx, diffs, deadline)...)
+}
+
+// diffLines diffs two texts reduced to one rune per line by linesToRunes (or
+// per word by wordsToRunes), using DiffAlgorithm.
+func (config *Config) diffLines(ctx context.Context, text1, text2 []rune, deadline time.Time) []Diff {
+	switch config.DiffAlgorithm {
+	case AlgorithmPatience:
+		return diffCompact(config.diffPatience(ctx, text1, text2, deadline))
+	case AlgorithmHistogram:
+		return diffCompact(config.diffHistogram(ctx, text1, text2, deadline))
+	}
+	return config.diffRunes(ctx, text1, text2, false, deadline)
+}
+
+// diffRediff rediffs any replacement blocks in a line-level diff, this time
+// character-by-character.
+func (config *Config) diffRediff(ctx context.Context, diffs []Diff, deadline time.Time) []Diff {
 	// Add a dummy entry at the end.
 	diffs = append(diffs, Diff{OpEqual, ""})
 	pointer := 0
@@ -179,7 +257,7 @@ func (config *Config) diffLineMode(text1, text2 []rune, deadline time.Time) []Di
 				diffs = splice(diffs, pointer-countDelete-countInsert,
 					countDelete+countInsert)
 				pointer = pointer - countDelete - countInsert
-				a := config.diffRunes([]rune(textDelete), []rune(textInsert), false, deadline)
+				a := config.diffRunes(ctx, []rune(textDelete), []rune(textInsert), false, deadline)
 				for j := len(a) - 1; j >= 0; j-- {
 					diffs = splice(diffs, pointer, 0, a[j])
 				}
@@ -195,6 +273,191 @@ func (config *Config) diffLineMode(text1, text2 []rune, deadline time.Time) []Di
 	return diffs[:len(diffs)-1] // Remove the dummy entry at the end.
 }
 
+// diffAppend appends diffs to a diff, joining adjacent diffs with the same
+// operation and dropping empty ones.  Unlike DiffCleanupMerge, edits are
+// never shifted, so anchored lines stay where they were matched.
+func diffAppend(diffs []Diff, more ...Diff) []Diff {
+	for _, d := range more {
+		switch n := len(diffs); {
+		case len(d.Text) == 0:
+		case n != 0 && diffs[n-1].Op == d.Op:
+			diffs[n-1].Text += d.Text
+		case n > 1 && d.Op == OpDelete && diffs[n-1].Op == OpInsert && diffs[n-2].Op == OpDelete:
+			// Keep deletions before insertions.
+			diffs[n-2].Text += d.Text
+		default:
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+// diffCompact slides each block of changed lines in a diff of texts reduced
+// to one rune per line as far up and then down as it will go, merging it
+// with any blocks it runs into, and then back up to line up with a change in
+// the other text where possible.  This is how git places changes among
+// repeated lines, such as closing braces.
+func diffCompact(diffs []Diff) []Diff {
+	var text1, text2 lineChanges
+	for _, d := range diffs {
+		for _, r := range d.Text {
+			if d.Op != OpInsert {
+				text1.lines = append(text1.lines, r)
+				text1.changed = append(text1.changed, d.Op == OpDelete)
+			}
+			if d.Op != OpDelete {
+				text2.lines = append(text2.lines, r)
+				text2.changed = append(text2.changed, d.Op == OpInsert)
+			}
+		}
+	}
+	text1.compact(text2)
+	text2.compact(text1)
+	var compacted []Diff
+	for i, j := 0, 0; i < len(text1.lines) || j < len(text2.lines); {
+		start1, start2 := i, j
+		for text1.at(i) {
+			i++
+		}
+		for text2.at(j) {
+			j++
+		}
+		compacted = diffAppend(compacted, Diff{OpDelete, string(text1.lines[start1:i])}, Diff{OpInsert, string(text2.lines[start2:j])})
+		// Unchanged lines are the same in both texts.
+		start1 = i
+		for i < len(text1.lines) && !text1.at(i) && !text2.at(j) {
+			i, j = i+1, j+1
+		}
+		compacted = diffAppend(compacted, Diff{OpEqual, string(text1.lines[start1:i])})
+	}
+	return compacted
+}
+
+// lineChanges marks the changed lines of a text for diffCompact.
+type lineChanges struct {
+	lines   []rune
+	changed []bool
+}
+
+// lineGroup is a (possibly empty) block of changed lines [start, end).
+type lineGroup struct {
+	start, end int
+}
+
+// compact slides the groups of changed lines in c, keeping other, the
+// changes to the other text, in step.
+func (c lineChanges) compact(other lineChanges) {
+	g, o := c.first(), other.first()
+	for {
+		if g.end != g.start {
+			// Slide up then down as far as possible, merging with any groups
+			// run into, until the group stops growing.
+			var earliestEnd, endMatchingOther int
+			for {
+				size := g.end - g.start
+				endMatchingOther = -1
+				for c.slideUp(&g) {
+					other.previous(&o)
+				}
+				earliestEnd = g.end
+				if o.end != o.start {
+					endMatchingOther = g.end
+				}
+				for c.slideDown(&g) {
+					other.next(&o)
+					if o.end != o.start {
+						endMatchingOther = g.end
+					}
+				}
+				if size == g.end-g.start {
+					break
+				}
+			}
+			// Line up with the last change in the other text it can.
+			if g.end != earliestEnd && endMatchingOther != -1 {
+				for o.end == o.start {
+					c.slideUp(&g)
+					other.previous(&o)
+				}
+			}
+		}
+		if !c.next(&g) {
+			return
+		}
+		other.next(&o)
+	}
+}
+
+// at reports whether line i is changed.
+func (c lineChanges) at(i int) bool {
+	return i >= 0 && i < len(c.changed) && c.changed[i]
+}
+
+// first returns the group at the start of the text.
+func (c lineChanges) first() lineGroup {
+	var g lineGroup
+	for c.at(g.end) {
+		g.end++
+	}
+	return g
+}
+
+// next moves g to the following group, reporting false at the end of the
+// text.
+func (c lineChanges) next(g *lineGroup) bool {
+	if g.end == len(c.changed) {
+		return false
+	}
+	g.start = g.end + 1
+	g.end = g.start
+	for c.at(g.end) {
+		g.end++
+	}
+	return true
+}
+
+// previous moves g to the preceding group, reporting false at the start of
+// the text.
+func (c lineChanges) previous(g *lineGroup) bool {
+	if g.start == 0 {
+		return false
+	}
+	g.end = g.start - 1
+	g.start = g.end
+	for c.at(g.start - 1) {
+		g.start--
+	}
+	return true
+}
+
+// slideDown moves g down a line if the line after it matches its first line,
+// merging it with any group it runs into.
+func (c lineChanges) slideDown(g *lineGroup) bool {
+	if g.end == len(c.lines) || c.lines[g.start] != c.lines[g.end] {
+		return false
+	}
+	c.changed[g.start], c.changed[g.end] = false, true
+	g.start, g.end = g.start+1, g.end+1
+	for c.at(g.end) {
+		g.end++
+	}
+	return true
+}
+
+// slideUp moves g up a line if the line before it matches its last line,
+// merging it with any group it runs into.
+func (c lineChanges) slideUp(g *lineGroup) bool {
+	if g.start == 0 || c.lines[g.start-1] != c.lines[g.end-1] {
+		return false
+	}
+	c.changed[g.start-1], c.changed[g.end-1] = true, false
+	g.start, g.end = g.start-1, g.end-1
+	for c.at(g.start - 1) {
+		g.start--
+	}
+	return true
+}
+
 // DiffBisect finds the 'middle snake' of a diff, split the problem in two and
 // return the recursively constructed diff. If an invalid UTF-8 sequence is
 // encountered, it will be replaced by the Unicode replacement character.
@@ -202,14 +465,14 @@ func (config *Config) diffLineMode(text1, text2 []rune, deadline time.Time) []Di
 // See Myers 1986 paper: An O(ND) Difference Algorithm and Its Variations.
 func (config *Config) DiffBisect(text1, text2 string, deadline time.Time) []Diff {
 	// Unused in this code, but retained for interface compatibility.
-	return config.diffBisect([]rune(text1), []rune(text2), deadline)
+	return config.diffBisect(context.Background(), []rune(text1), []rune(text2), deadline)
 }
 
 // diffBisect finds the 'middle snake' of a diff, splits the problem in two and
 // returns the recursively constructed diff.
 //
 // See Myers's 1986 paper: An O(ND) Difference Algorithm and Its Variations.
-func (config *Config) diffBisect(runes1, runes2 []rune, deadline time.Time) []Diff {
+func (config *Config) diffBisect(ctx context.Context, runes1, runes2 []rune, deadline time.Time) []Diff {
 	// Cache the text lengths to prevent multiple calls.
 	runes1Len, runes2Len := len(runes1), len(runes2)
 	maxD := (runes1Len + runes2Len + 1) / 2
@@ -234,8 +497,8 @@ func (config *Config) diffBisect(runes1, runes2 []rune, deadline time.Time) []Di
 	k2start := 0
 	k2end := 0
 	for d := 0; d < maxD; d++ {
-		// Bail out if deadline is reached.
-		if !deadline.IsZero() && d%16 == 0 && time.Now().After(deadline) {
+		// Bail out if deadline is reached or the context is done.
+		if d%16 == 0 && (ctx.Err() != nil || !deadline.IsZero() && time.Now().After(deadline)) {
 			break
 		}
 		// Walk the front path one step.
@@ -269,7 +532,7 @@ func (config *Config) diffBisect(runes1, runes2 []rune, deadline time.Time) []Di
 					x2 := runes1Len - v2[k2Offset]
 					if x1 >= x2 {
 						// Overlap detected.
-						return config.diffBisectSplit(runes1, runes2, x1, y1, deadline)
+						return config.diffBisectSplit(ctx, runes1, runes2, x1, y1, deadline)
 					}
 				}
 			}
@@ -307,7 +570,7 @@ func (config *Config) diffBisect(runes1, runes2 []rune, deadline time.Time) []Di
 					x2 = runes1Len - x2
 					if x1 >= x2 {
 						// Overlap detected.
-						return config.diffBisectSplit(runes1, runes2, x1, y1, deadline)
+						return config.diffBisectSplit(ctx, runes1, runes2, x1, y1, deadline)
 					}
 				}
 			}
@@ -321,12 +584,12 @@ func (config *Config) diffBisect(runes1, runes2 []rune, deadline time.Time) []Di
 	}
 }
 
-func (config *Config) diffBisectSplit(runes1, runes2 []rune, x, y int, deadline time.Time) []Diff {
+func (config *Config) diffBisectSplit(ctx context.Context, runes1, runes2 []rune, x, y int, deadline time.Time) []Diff {
 	runes1a, runes1b := runes1[:x], runes1[x:]
 	runes2a, runes2b := runes2[:y], runes2[y:]
 	// Compute both diffs serially.
-	diffs := config.diffRunes(runes1a, runes2a, false, deadline)
-	diffsb := config.diffRunes(runes1b, runes2b, false, deadline)
+	diffs := config.diffRunes(ctx, runes1a, runes2a, false, deadline)
+	diffsb := config.diffRunes(ctx, runes1b, runes2b, false, deadline)
 	return append(diffs, diffsb...)
 }
 
@@ -335,33 +598,21 @@ func (config *Config) diffBisectSplit(runes1, runes2 []rune, x, y int, deadline
 // line. It's slightly faster to call DiffLinesToRunes first, followed by
 // DiffRunes.
 func (config *Config) DiffLinesToChars(text1, text2 string) (string, string, []string) {
-	chars1, chars2, lineArray := config.diffLinesToStrings(text1, text2)
-	return chars1, chars2, lineArray
+	runes1, runes2, lineArray := linesToRunes(text1, text2)
+	return string(runes1), string(runes2), lineArray
 }
 
-// DiffLinesToRunes splits two texts into a list of runes.
+// DiffLinesToRunes splits two texts into a list of runes, where each rune
+// represents one line.  A line occurring in both texts is represented by the
+// same rune.
 func (config *Config) DiffLinesToRunes(text1, text2 string) ([]rune, []rune, []string) {
-	chars1, chars2, lineArray := config.diffLinesToStrings(text1, text2)
-	return []rune(chars1), []rune(chars2), lineArray
+	return linesToRunes(text1, text2)
 }
 
 // DiffCharsToLines rehydrates the text in a diff from a string of line hashes
 // to real lines of text.
 func (config *Config) DiffCharsToLines(diffs []Diff, lineArray []string) []Diff {
-	hydrated := make([]Diff, 0, len(diffs))
-	for _, d := range diffs {
-		chars := strings.Split(d.Text, ",")
-		text := make([]string, len(chars))
-		for i, r := range chars {
-			i1, err := strconv.Atoi(r)
-			if err == nil {
-				text[i] = lineArray[i1]
-			}
-		}
-		d.Text = strings.Join(text, "")
-		hydrated = append(hydrated, d)
-	}
-	return hydrated
+	return runesToLines(diffs, lineArray)
 }
 
 // DiffCommonPrefix determines the common prefix length of two strings.
@@ -1004,44 +1255,14 @@ func (config *Config) DiffXIndex(diffs []Diff, loc int) int {
 // as an example from which to write one's own display functions.
 func (config *Config) DiffPrettyHtml(diffs []Diff) string {
 	var buf bytes.Buffer
-	for _, d := range diffs {
-		text := strings.Replace(html.EscapeString(d.Text), "\n", "&para;<br>", -1)
-		switch d.Op {
-		case OpInsert:
-			_, _ = buf.WriteString("<ins style=\"background:#e6ffe6;\">")
-			_, _ = buf.WriteString(text)
-			_, _ = buf.WriteString("</ins>")
-		case OpDelete:
-			_, _ = buf.WriteString("<del style=\"background:#ffe6e6;\">")
-			_, _ = buf.WriteString(text)
-			_, _ = buf.WriteString("</del>")
-		case OpEqual:
-			_, _ = buf.WriteString("<span>")
-			_, _ = buf.WriteString(text)
-			_, _ = buf.WriteString("</span>")
-		}
-	}
+	_ = config.Render(&buf, HtmlRenderer{}, diffs)
 	return buf.String()
 }
 
 // DiffPrettyText converts a []Diff into a colored text report.
 func (config *Config) DiffPrettyText(diffs []Diff) string {
 	var buf bytes.Buffer
-	for _, d := range diffs {
-		text := d.Text
-		switch d.Op {
-		case OpInsert:
-			_, _ = buf.WriteString("\x1b[32m")
-			_, _ = buf.WriteString(text)
-			_, _ = buf.WriteString("\x1b[0m")
-		case OpDelete:
-			_, _ = buf.WriteString("\x1b[31m")
-			_, _ = buf.WriteString(text)
-			_, _ = buf.WriteString("\x1b[0m")
-		case OpEqual:
-			_, _ = buf.WriteString(text)
-		}
-	}
+	_ = config.Render(&buf, TextRenderer{}, diffs)
 	return buf.String()
 }
 
@@ -1098,38 +1319,38 @@ func (config *Config) DiffLevenshtein(diffs []Diff) int {
 // Inserted text is escaped using %xx notation.
 func (config *Config) DiffToDelta(diffs []Diff) string {
 	var buf bytes.Buffer
-	for _, d := range diffs {
-		switch d.Op {
-		case OpInsert:
-			_, _ = buf.WriteString("+")
-			_, _ = buf.WriteString(strings.Replace(url.QueryEscape(d.Text), "+", " ", -1))
-			_, _ = buf.WriteString("\t")
-		case OpDelete:
-			_, _ = buf.WriteString("-")
-			_, _ = buf.WriteString(strconv.Itoa(utf8.RuneCountInString(d.Text)))
-			_, _ = buf.WriteString("\t")
-		case OpEqual:
-			_, _ = buf.WriteString("=")
-			_, _ = buf.WriteString(strconv.Itoa(utf8.RuneCountInString(d.Text)))
-			_, _ = buf.WriteString("\t")
-		}
-	}
-	delta := buf.String()
-	if len(delta) != 0 {
-		// Strip off trailing tab character.
-		delta = delta[0 : utf8.RuneCountInString(delta)-1]
-		delta = unescaper.Replace(delta)
-	}
-	return delta
+	_ = config.WriteDelta(&buf, diffs)
+	return buf.String()
+}
+
+// WriteDelta writes the delta of diffs to w, as returned by DiffToDelta, a
+// diff at a time.
+func (config *Config) WriteDelta(w io.Writer, diffs []Diff) error {
+	return config.Render(w, &DeltaRenderer{}, diffs)
 }
 
 // DiffFromDelta given the original text1, and an encoded string which
 // describes the operations required to transform text1 into text2, comAdde the
 // full diff.
 func (config *Config) DiffFromDelta(text1 string, delta string) (diffs []Diff, err error) {
+	return config.ReadDelta(text1, strings.NewReader(delta))
+}
+
+// ReadDelta reads and decodes a delta from r, a token at a time, as
+// DiffFromDelta.
+func (config *Config) ReadDelta(text1 string, r io.Reader) (diffs []Diff, err error) {
+	br := bufio.NewReader(r)
 	i := 0
 	runes := []rune(text1)
-	for _, token := range strings.Split(delta, "\t") {
+	for done := false; !done; {
+		token, err := br.ReadString('\t')
+		switch {
+		case err == io.EOF:
+			done = true
+		case err != nil:
+			return nil, err
+		}
+		token = strings.TrimSuffix(token, "\t")
 		if len(token) == 0 {
 			// Blank tokens are ok (from a trailing \t).
 			continue
@@ -1180,43 +1401,72 @@ func (config *Config) DiffFromDelta(text1 string, delta string) (diffs []Diff, e
 	return diffs, nil
 }
 
-// diffLinesToStrings splits two texts into a list of strings. Each string
-// represents one line.
-func (config *Config) diffLinesToStrings(text1, text2 string) (string, string, []string) {
+// maxLineIndex is the largest line index that linesToRunes can represent as a
+// rune.
+const maxLineIndex = utf8.MaxRune - (0xdfff - 0xd800 + 1)
+
+// linesToRunes splits two texts into lines, and reduces the texts to rune
+// slices where each rune represents one line. Returns the rune slices and
+// the lines, indexed by rune value.
+func linesToRunes(text1, text2 string) ([]rune, []rune, []string) {
 	// '\x00' is a valid character, but various debuggers don't like it. So
 	// we'll insert a junk entry to avoid generating a null character.
-	lineArray := []string{""} // e.g. lineArray[4] == 'Hello\n'
-	// Each string has the index of lineArray which it points to
-	strIndexArray1 := config.diffLinesToStringsMunge(text1, &lineArray)
-	strIndexArray2 := config.diffLinesToStringsMunge(text2, &lineArray)
-	return intArrayToString(strIndexArray1), intArrayToString(strIndexArray2), lineArray
+	lines := []string{""}
+	lineHash := map[string]rune{}
+	return linesToRunesMunge(text1, &lines, lineHash), linesToRunesMunge(text2, &lines, lineHash), lines
 }
 
-// diffLinesToStringsMunge splits a text into an array of strings, and reduces
-// the texts to a []string.
-func (config *Config) diffLinesToStringsMunge(text string, lineArray *[]string) []uint32 {
-	// Walk the text, pulling out a substring for each line. text.split('\n')
-	// would would temporarily double our memory footprint. Modifying text
-	// would create many large strings to garbage collect.
-	lineHash := map[string]int{} // e.g. lineHash['Hello\n'] == 4
-	lineStart := 0
-	lineEnd := -1
-	strs := []uint32{}
-	for lineEnd < len(text)-1 {
-		lineEnd = indexOf(text, "\n", lineStart)
-		if lineEnd == -1 {
-			lineEnd = len(text) - 1
+// linesToRunesMunge splits a text into lines, and reduces the text to a rune
+// slice where each rune represents one line.
+func linesToRunesMunge(text string, lines *[]string, lineHash map[string]rune) []rune {
+	var runes []rune
+	for len(text) != 0 {
+		i := strings.IndexByte(text, '\n') + 1
+		if i == 0 || len(*lines) >= maxLineIndex-1 {
+			// Last line, or out of runes (keeping one for the rest of each
+			// text): use the rest of the text.
+			i = len(text)
 		}
-		line := text[lineStart : lineEnd+1]
-		lineStart = lineEnd + 1
-		lineValue, ok := lineHash[line]
-		if ok {
-			strs = append(strs, uint32(lineValue))
-		} else {
-			*lineArray = append(*lineArray, line)
-			lineHash[line] = len(*lineArray) - 1
-			strs = append(strs, uint32(len(*lineArray)-1))
+		line := text[:i]
+		text = text[i:]
+		r, ok := lineHash[line]
+		if !ok {
+			r = lineRune(len(*lines))
+			*lines = append(*lines, line)
+			lineHash[line] = r
+		}
+		runes = append(runes, r)
+	}
+	return runes
+}
+
+// lineRune returns the rune representing the i'th line, skipping the
+// surrogate range which cannot be encoded in a string.
+func lineRune(i int) rune {
+	if i >= 0xd800 {
+		i += 0xdfff - 0xd800 + 1
+	}
+	return rune(i)
+}
+
+// lineIndex is the inverse of lineRune.
+func lineIndex(r rune) int {
+	if r > 0xdfff {
+		r -= 0xdfff - 0xd800 + 1
+	}
+	return int(r)
+}
+
+// runesToLines rehydrates the text in a diff from runes produced by
+// linesToRunes (or wordsToRunes) to real lines (or words) of text.
+func runesToLines(diffs []Diff, lines []string) []Diff {
+	hydrated := make([]Diff, 0, len(diffs))
+	for _, d := range diffs {
+		var buf strings.Builder
+		for _, r := range d.Text {
+			_, _ = buf.WriteString(lines[lineIndex(r)])
 		}
+		hydrated = append(hydrated, Diff{d.Op, buf.String()})
 	}
-	return strs
+	return hydrated
 }
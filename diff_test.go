@@ -1,13 +1,18 @@
 package diffmatchpatch
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 	"unicode/utf8"
 
@@ -216,7 +221,7 @@ func TestDiffBisectSplit(t *testing.T) {
 	}
 	config := NewDefaultConfig()
 	for _, test := range tests {
-		diffs := config.diffBisectSplit([]rune(test.Text1),
+		diffs := config.diffBisectSplit(context.Background(), []rune(test.Text1),
 			[]rune(test.Text2), 7, 6, time.Now().Add(time.Hour))
 		for _, d := range diffs {
 			assert.True(t, utf8.ValidString(d.Text))
@@ -237,21 +242,21 @@ func TestDiffLinesToChars(t *testing.T) {
 			"",
 			"alpha\r\nbeta\r\n\r\n\r\n",
 			"",
-			"1,2,3,3",
+			"\x01\x02\x03\x03",
 			[]string{"", "alpha\r\n", "beta\r\n", "\r\n"},
 		},
 		{
 			"a",
 			"b",
-			"1",
-			"2",
+			"\x01",
+			"\x02",
 			[]string{"", "a", "b"},
 		},
 		// Omit final newline.
 		{
 			"alpha\nbeta\nalpha",
 			"",
-			"1,2,3",
+			"\x01\x02\x03",
 			"",
 			[]string{"", "alpha\n", "beta\n", "alpha"},
 		},
@@ -268,20 +273,86 @@ func TestDiffLinesToChars(t *testing.T) {
 	lineList := []string{
 		"", // Account for the initial empty element of the lines array.
 	}
-	var charList []string
+	var charList []rune
 	for x := 1; x < n+1; x++ {
 		lineList = append(lineList, strconv.Itoa(x)+"\n")
-		charList = append(charList, strconv.Itoa(x))
+		charList = append(charList, rune(x))
 	}
 	lines := strings.Join(lineList, "")
-	chars := strings.Join(charList[:], ",")
-	assert.Equal(t, n, len(strings.Split(chars, ",")))
+	chars := string(charList)
+	assert.Equal(t, n, utf8.RuneCountInString(chars))
 	actualChars1, actualChars2, actualLines := config.DiffLinesToChars(lines, "")
 	assert.Equal(t, chars, actualChars1)
 	assert.Equal(t, "", actualChars2)
 	assert.Equal(t, lineList, actualLines)
 }
 
+func TestLinesToRunes(t *testing.T) {
+	tests := []struct {
+		Text1          string
+		Text2          string
+		ExpectedRunes1 []rune
+		ExpectedRunes2 []rune
+		ExpectedLines  []string
+	}{
+		{
+			"",
+			"alpha\r\nbeta\r\n\r\n\r\n",
+			nil,
+			[]rune{1, 2, 3, 3},
+			[]string{"", "alpha\r\n", "beta\r\n", "\r\n"},
+		},
+		{
+			"a",
+			"b",
+			[]rune{1},
+			[]rune{2},
+			[]string{"", "a", "b"},
+		},
+		// Shared lines.
+		{
+			"alpha\nbeta\nalpha",
+			"beta\nalpha\nbeta\n",
+			[]rune{1, 2, 3},
+			[]rune{2, 1, 2},
+			[]string{"", "alpha\n", "beta\n", "alpha"},
+		},
+	}
+	for i, test := range tests {
+		actualRunes1, actualRunes2, actualLines := linesToRunes(test.Text1, test.Text2)
+		assert.Equal(t, test.ExpectedRunes1, actualRunes1, fmt.Sprintf("Test case #%d, %#v", i, test))
+		assert.Equal(t, test.ExpectedRunes2, actualRunes2, fmt.Sprintf("Test case #%d, %#v", i, test))
+		assert.Equal(t, test.ExpectedLines, actualLines, fmt.Sprintf("Test case #%d, %#v", i, test))
+	}
+	// More lines than the surrogate range to reveal any encoding limitations.
+	n := 0xe000
+	var lineList []string
+	for x := 0; x < n; x++ {
+		lineList = append(lineList, strconv.Itoa(x)+"\n")
+	}
+	lines := strings.Join(lineList, "")
+	runes1, runes2, lineArray := linesToRunes(lines, "")
+	assert.Len(t, runes1, n)
+	assert.Nil(t, runes2)
+	assert.True(t, utf8.ValidString(string(runes1)))
+	diffs := runesToLines([]Diff{{OpDelete, string(runes1)}}, lineArray)
+	assert.Equal(t, []Diff{{OpDelete, lines}}, diffs)
+}
+
+func TestDiffLinesToRunes(t *testing.T) {
+	text1 := "dd\ncd\nace ae\nbacacbdabdc \ndbcd dc adadecd\nccb eab\n\ndb\n e \nb\ne\ndc  bec cdd\nc\ne\ncacbec c \naba c\nabaeaadde\neb"
+	text2 := "bc\nba  a\nee cb  \naeb\ncad\neceaab\n c\ne acd b  \n\nbae  \nd\nd\nd  e \n\nabd dd\ne\nb\nbaccece b\n  ebacbadadec\nbbebb \n da  cebeee\nebccb \n\ned\ncdbdadd\n\ncd\nc\ncc\nc bbda abdab b"
+	config := NewDefaultConfig()
+	runes1, runes2, lines := config.DiffLinesToRunes(text1, text2)
+	assert.Equal(t, strings.Count(text1, "\n")+1, len(runes1))
+	assert.Equal(t, strings.Count(text2, "\n")+1, len(runes2))
+	diffs := config.DiffCharsToLines(config.DiffRunes(runes1, runes2, false), lines)
+	assert.Equal(t, []string{text1, text2}, diffRebuildTexts(diffs))
+	// Line mode diffs rebuild the texts too.
+	config.DiffTimeout = 0
+	assert.Equal(t, []string{text1, text2}, diffRebuildTexts(config.Diff(text1, text2, true)))
+}
+
 func TestDiffCharsToLines(t *testing.T) {
 	tests := []struct {
 		Diffs    []Diff
@@ -290,8 +361,8 @@ func TestDiffCharsToLines(t *testing.T) {
 	}{
 		{
 			Diffs: []Diff{
-				{OpEqual, "1,2,1"},
-				{OpInsert, "2,1,2"},
+				{OpEqual, "\x01\x02\x01"},
+				{OpInsert, "\x02\x01\x02"},
 			},
 			Lines: []string{"", "alpha\n", "beta\n"},
 			Expected: []Diff{
@@ -310,13 +381,13 @@ func TestDiffCharsToLines(t *testing.T) {
 	lineList := []string{
 		"", // Account for the initial empty element of the lines array.
 	}
-	charList := []string{}
+	charList := []rune{}
 	for x := 1; x <= n; x++ {
 		lineList = append(lineList, strconv.Itoa(x)+"\n")
-		charList = append(charList, strconv.Itoa(x))
+		charList = append(charList, rune(x))
 	}
 	assert.Equal(t, n, len(charList))
-	chars := strings.Join(charList[:], ",")
+	chars := string(charList)
 	actual := config.DiffCharsToLines([]Diff{Diff{OpDelete, chars}}, lineList)
 	assert.Equal(t, []Diff{Diff{OpDelete, strings.Join(lineList, "")}}, actual)
 }
@@ -1135,6 +1206,59 @@ func TestDiffDelta(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestWriteDelta(t *testing.T) {
+	diffs := []Diff{
+		{OpEqual, "jump"},
+		{OpDelete, "s"},
+		{OpInsert, "ed\n"},
+		{OpEqual, " over "},
+		{OpInsert, "a"},
+	}
+	config := NewDefaultConfig()
+	delta := config.DiffToDelta(diffs)
+	assert.Equal(t, "=4\t-1\t+ed%0A\t=6\t+a", delta)
+	var buf bytes.Buffer
+	assert.Nil(t, config.WriteDelta(&buf, diffs))
+	assert.Equal(t, delta, buf.String())
+	// Write errors are returned.
+	for n := 0; n < len(delta); n++ {
+		assert.EqualError(t, config.WriteDelta(&errWriter{n}, diffs), "write failed", fmt.Sprintf("n = %d", n))
+	}
+}
+
+func TestReadDelta(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Text  string
+		Delta string
+		Error string
+	}{
+		{"Empty", "", "", ""},
+		{"Delta", "jumps over", "=4\t-1\t+ed%0A\t=5", ""},
+		{"Trailing tab", "jumps over", "=4\t-1\t+ed\t=5\t", ""},
+		{"Delta too long", "jumps", "=4\t-2", "Delta length (6) is different from source text length (5)"},
+		{"Invalid diff operation", "", "a", "Invalid diff operation in DiffFromDelta: a"},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		expected, expectedErr := config.DiffFromDelta(test.Text, test.Delta)
+		actual, err := config.ReadDelta(test.Text, iotest.OneByteReader(strings.NewReader(test.Delta)))
+		assert.Equal(t, expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		if test.Error == "" {
+			assert.Nil(t, err, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+			assert.Nil(t, expectedErr, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+			assert.Equal(t, test.Text, config.DiffText1(actual), fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		} else {
+			assert.EqualError(t, err, test.Error, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+			assert.EqualError(t, expectedErr, test.Error, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		}
+	}
+	// Read errors are returned.
+	r := io.MultiReader(strings.NewReader("=4\t"), iotest.ErrReader(errors.New("read failed")))
+	_, err := config.ReadDelta("jumps", r)
+	assert.EqualError(t, err, "read failed")
+}
+
 func TestDiffXIndex(t *testing.T) {
 	tests := []struct {
 		Name     string
@@ -1443,6 +1567,37 @@ func TestDiffWithTimeout(t *testing.T) {
 	assert.True(t, delta < (config.DiffTimeout*100), fmt.Sprintf("%v !< %v", delta, config.DiffTimeout*100))
 }
 
+func TestDiffContext(t *testing.T) {
+	config := NewDefaultConfig()
+	config.DiffTimeout = 0
+	a := "`Twas brillig, and the slithy toves\nDid gyre and gimble in the wabe:\nAll mimsy were the borogoves,\nAnd the mome raths outgrabe.\n"
+	b := "I am the very model of a modern major general,\nI've information vegetable, animal, and mineral,\nI know the kings of England, and I quote the fights historical,\nFrom Marathon to Waterloo, in order categorical.\n"
+	// Not done.
+	diffs, err := config.DiffContext(context.Background(), a, b, false)
+	assert.Nil(t, err)
+	assert.Equal(t, config.Diff(a, b, false), diffs)
+	// Already canceled.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	diffs, err = config.DiffContext(ctx, a, b, false)
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, diffs)
+	// Increase the text lengths by 1024 times to ensure the context expires
+	// first.
+	for x := 0; x < 10; x++ {
+		a = a + a
+		b = b + b
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	startTime := time.Now()
+	diffs, err = config.DiffContext(ctx, a, b, true)
+	delta := time.Since(startTime)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Nil(t, diffs)
+	assert.True(t, delta < time.Second, fmt.Sprintf("%v !< %v", delta, time.Second))
+}
+
 func TestDiffWithCheckLines(t *testing.T) {
 	tests := []struct {
 		Text1 string
@@ -1613,3 +1768,36 @@ func BenchmarkDiffCleanupSemantic(b *testing.B) {
 		config.DiffCleanupSemantic(diffs)
 	}
 }
+
+func TestDiffCompact(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Diffs    []Diff
+		Expected []Diff
+	}{
+		{
+			"Slide down",
+			[]Diff{{OpEqual, "a}"}, {OpInsert, "}b"}, {OpEqual, "}c"}},
+			[]Diff{{OpEqual, "a}}"}, {OpInsert, "b}"}, {OpEqual, "c"}},
+		},
+		{
+			"Merge with the next change",
+			[]Diff{{OpEqual, "a"}, {OpDelete, "b"}, {OpEqual, "b"}, {OpDelete, "c"}},
+			[]Diff{{OpEqual, "ab"}, {OpDelete, "bc"}},
+		},
+		{
+			"Line up with a change in the other text",
+			[]Diff{{OpEqual, "x"}, {OpDelete, "a"}, {OpInsert, "b"}, {OpEqual, "y"}, {OpInsert, "zy"}, {OpEqual, "w"}},
+			[]Diff{{OpEqual, "x"}, {OpDelete, "a"}, {OpInsert, "byz"}, {OpEqual, "yw"}},
+		},
+		{
+			"Nothing to slide",
+			[]Diff{{OpEqual, "a"}, {OpDelete, "b"}, {OpInsert, "c"}, {OpEqual, "d"}},
+			[]Diff{{OpEqual, "a"}, {OpDelete, "b"}, {OpInsert, "c"}, {OpEqual, "d"}},
+		},
+	}
+	for i, test := range tests {
+		actual := diffCompact(test.Diffs)
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
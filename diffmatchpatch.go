@@ -13,23 +13,40 @@ type Config struct {
 	DiffTimeout time.Duration
 	// Cost of an empty edit operation in terms of edit characters.
 	DiffEditCost int
+	// DiffAlgorithm is the algorithm used to compute diffs.  Algorithms other
+	// than AlgorithmMyers diff texts line by line, then rediff the changed
+	// blocks character by character with AlgorithmMyers.
+	DiffAlgorithm Algorithm
+	// DiffWordBoundary splits texts into words for DiffWords.  If nil,
+	// DefaultWordBoundary is used.
+	DiffWordBoundary WordBoundary
 
 	// How far to search for a match (0 = exact location, 1000+ = broad match).
 	// A match this many characters away from the expected location will add
 	// 1.0 to the score (0.0 is a perfect match).
 	MatchDistance int
-	// The number of bits in an int.
+	// The maximum length of the pattern PatchAddContext grows the context of
+	// a patch to, while looking for a unique pattern.
 	MatchMaxBits int
 	// At what point is no match declared (0.0 = perfection, 1.0 = very loose).
 	MatchThreshold float64
 
-	// When deleting a large block of text (over ~64 characters), how close do
-	// the contents have to be to match the expected contents. (0.0 =
-	// perfection, 1.0 = very loose).  Note that MatchThreshold controls how
-	// closely the end points of a delete need to match.
+	// When deleting a large block of text (over PatchMaxLength characters),
+	// how close do the contents have to be to match the expected contents.
+	// (0.0 = perfection, 1.0 = very loose).  Such a delete is located by its
+	// first and last PatchMaxLength characters, and only applied if it
+	// matches this closely.
 	PatchDeleteThreshold float64
+	// The maximum length of a patch when applied.  Longer patches are split
+	// into pieces no longer than this, except for large deletes.  0 keeps
+	// patches whole, with their full context, as patterns of any length can
+	// be matched.
+	PatchMaxLength int
 	// Chunk size for context length.
 	PatchMargin int
+
+	// Number of unchanged lines of context around changes in unified diffs.
+	UnifiedContext int
 }
 
 // NewDefaultConfig creates a new configuration with default parameters.
@@ -41,6 +58,92 @@ func NewDefaultConfig() *Config {
 		MatchDistance:        1000,
 		MatchMaxBits:         32,
 		PatchDeleteThreshold: 0.5,
+		PatchMaxLength:       32,
 		PatchMargin:          4,
+		UnifiedContext:       3,
+	}
+}
+
+// Option is a functional option for the package-level functions.
+type Option func(*Config)
+
+// WithTimeout sets the DiffTimeout (0 for infinity).
+func WithTimeout(timeout time.Duration) Option {
+	return func(config *Config) {
+		config.DiffTimeout = timeout
+	}
+}
+
+// WithEditCost sets the DiffEditCost.
+func WithEditCost(cost int) Option {
+	return func(config *Config) {
+		config.DiffEditCost = cost
+	}
+}
+
+// WithMatchThreshold sets the MatchThreshold.
+func WithMatchThreshold(threshold float64) Option {
+	return func(config *Config) {
+		config.MatchThreshold = threshold
+	}
+}
+
+// defaultConfig is the config used by the package-level functions.
+var defaultConfig = NewDefaultConfig()
+
+// configWith returns defaultConfig, or a copy of it with opts applied.
+func configWith(opts []Option) *Config {
+	if len(opts) == 0 {
+		return defaultConfig
+	}
+	config := *defaultConfig
+	for _, o := range opts {
+		o(&config)
 	}
+	return &config
+}
+
+// DiffMain finds the differences between two texts using the default config.
+// See Config.Diff.
+func DiffMain(text1, text2 string, checklines bool, opts ...Option) []Diff {
+	return configWith(opts).Diff(text1, text2, checklines)
+}
+
+// DiffPrettyText converts a []Diff into a colored text report.
+func DiffPrettyText(diffs []Diff) string {
+	return defaultConfig.DiffPrettyText(diffs)
+}
+
+// DiffPrettyHtml converts a []Diff into a pretty HTML report.
+func DiffPrettyHtml(diffs []Diff) string {
+	return defaultConfig.DiffPrettyHtml(diffs)
+}
+
+// Match locates the best instance of pattern in text near loc using the
+// default config.  Returns -1 if no match found.  See Config.Match.
+func Match(text, pattern string, loc int, opts ...Option) int {
+	return configWith(opts).Match(text, pattern, loc)
+}
+
+// PatchMake computes a list of patches to turn text1 into text2 using the
+// default config.  See Config.PatchMake.
+func PatchMake(text1, text2 string, opts ...Option) []Patch {
+	return configWith(opts).PatchMakeFromTexts(text1, text2)
+}
+
+// PatchApply applies patches to text using the default config.  Returns the
+// patched text and whether each patch was applied.  See Config.PatchApply.
+func PatchApply(patches []Patch, text string, opts ...Option) (string, []bool) {
+	return configWith(opts).PatchApply(patches, text)
+}
+
+// PatchToText converts a list of patches to a textual representation.
+func PatchToText(patches []Patch) string {
+	return defaultConfig.PatchToText(patches)
+}
+
+// PatchFromText parses a textual representation of patches and returns a
+// list of patches.
+func PatchFromText(text string) ([]Patch, error) {
+	return defaultConfig.PatchFromText(text)
 }
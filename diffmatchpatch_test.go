@@ -0,0 +1,57 @@
+package diffmatchpatch
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigWith(t *testing.T) {
+	assert.True(t, configWith(nil) == defaultConfig)
+
+	config := configWith([]Option{WithTimeout(time.Minute), WithEditCost(7), WithMatchThreshold(0.25)})
+	assert.Equal(t, time.Minute, config.DiffTimeout)
+	assert.Equal(t, 7, config.DiffEditCost)
+	assert.Equal(t, 0.25, config.MatchThreshold)
+	assert.Equal(t, defaultConfig.MatchDistance, config.MatchDistance)
+	// The default config is left alone.
+	assert.Equal(t, NewDefaultConfig(), defaultConfig)
+}
+
+func TestPackageFunctions(t *testing.T) {
+	diffs := DiffMain("Lorem ipsum dolor.", "Lorem dolor sit amet.", false)
+	assert.Equal(t, NewDefaultConfig().Diff("Lorem ipsum dolor.", "Lorem dolor sit amet.", false), diffs)
+	assert.Equal(t, "Lorem \x1b[31mipsum \x1b[0mdolor\x1b[32m sit amet\x1b[0m.", DiffPrettyText(diffs))
+	assert.Equal(t, "<span>a</span><del style=\"background:#ffe6e6;\">b</del>", DiffPrettyHtml([]Diff{{OpEqual, "a"}, {OpDelete, "b"}}))
+
+	tests := []struct {
+		Name     string
+		Text     string
+		Pattern  string
+		Loc      int
+		Opts     []Option
+		Expected int
+	}{
+		{"Exact", "abcdefghijk", "fgh", 5, nil, 5},
+		{"Fuzzy", "abcdefghijk", "efxhi", 0, nil, 4},
+		{"Too fuzzy for threshold", "abcdefghijk", "efxyhi", 1, []Option{WithMatchThreshold(0.2)}, -1},
+		{"Loose threshold", "abcdefghijk", "efxyhi", 1, []Option{WithMatchThreshold(0.5)}, 4},
+	}
+	for i, test := range tests {
+		actual := Match(test.Text, test.Pattern, test.Loc, test.Opts...)
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+
+	text1 := "The quick brown fox jumps over the lazy dog."
+	text2 := "That quick brown fox jumped over a lazy dog."
+	patches := PatchMake(text1, text2, WithEditCost(5))
+	text := PatchToText(patches)
+	assert.Equal(t, "@@ -1,11 +1,12 @@\n Th\n-e\n+at\n  quick b\n@@ -22,18 +22,17 @@\n jump\n-s\n+ed\n  over \n-the\n+a\n  laz\n", text)
+	patches, err := PatchFromText(text)
+	assert.Nil(t, err)
+	actual, applied := PatchApply(patches, "The quick red rabbit jumps over the tired tiger.")
+	assert.Equal(t, "That quick red rabbit jumped over a tired tiger.", actual)
+	assert.Equal(t, []bool{true, true}, applied)
+}
@@ -1,11 +1,11 @@
 module github.com/kenshaw/diffmatchpatch
 
+require github.com/stretchr/testify v1.4.0
+
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/kr/pretty v0.1.0 // indirect
-	github.com/stretchr/testify v1.4.0
-	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	gopkg.in/yaml.v2 v2.2.4 // indirect
 )
 
-go 1.15
+go 1.18
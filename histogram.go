@@ -0,0 +1,111 @@
+package diffmatchpatch
+
+import (
+	"context"
+	"time"
+)
+
+// histogramMaxChain is the number of times a line may occur in a region of
+// text1 and still be used to split the region, as in git.
+const histogramMaxChain = 64
+
+// diffHistogram finds the differences between two texts reduced to one rune
+// per line by linesToRunes, using git's histogram algorithm: the region is
+// split on the longest common run of lines containing the line that occurs
+// least often in text1, and both sides are diffed recursively.  Regions that
+// only have overly common lines in common are diffed with diffRunes.
+func (config *Config) diffHistogram(ctx context.Context, text1, text2 []rune, deadline time.Time) []Diff {
+	return config.diffHistogramAppend(ctx, nil, text1, text2, deadline)
+}
+
+// diffHistogramAppend appends the differences between text1 and text2 to
+// diffs.
+func (config *Config) diffHistogramAppend(ctx context.Context, diffs []Diff, text1, text2 []rune, deadline time.Time) []Diff {
+	for {
+		if ctx.Err() != nil || len(text1) == 0 || len(text2) == 0 {
+			return diffAppend(diffs, Diff{OpDelete, string(text1)}, Diff{OpInsert, string(text2)})
+		}
+		start1, end1, start2, end2, common := histogramLCS(text1, text2)
+		if end1 == 0 {
+			if common {
+				// Only overly common lines are shared.
+				return diffAppend(diffs, config.diffRunes(ctx, text1, text2, false, deadline)...)
+			}
+			return diffAppend(diffs, Diff{OpDelete, string(text1)}, Diff{OpInsert, string(text2)})
+		}
+		diffs = config.diffHistogramAppend(ctx, diffs, text1[:start1], text2[:start2], deadline)
+		diffs = diffAppend(diffs, Diff{OpEqual, string(text1[start1:end1])})
+		text1, text2 = text1[end1:], text2[end2:]
+	}
+}
+
+// histogramLCS finds the longest common run of lines text1[start1:end1] and
+// text2[start2:end2] containing the line occurring least often in text1.
+// end1 is 0 if there is no such run, in which case common reports whether
+// the texts have any lines in common at all.
+func histogramLCS(text1, text2 []rune) (start1, end1, start2, end2 int, common bool) {
+	// Index the occurrences of each line in text1.
+	counts := make(map[rune]int)
+	next := make([]int, len(text1))
+	head := make(map[rune]int)
+	for i := len(text1) - 1; i >= 0; i-- {
+		r := text1[i]
+		if j, ok := head[r]; ok {
+			next[i] = j
+		} else {
+			next[i] = -1
+		}
+		head[r] = i
+		counts[r]++
+	}
+	// The lowest occurrence count of any run found so far.
+	low := histogramMaxChain + 1
+	for b := 0; b < len(text2); {
+		bnext := b + 1
+		r := text2[b]
+		i, ok := head[r]
+		if !ok {
+			b = bnext
+			continue
+		}
+		if counts[r] > low {
+			common = true
+			b = bnext
+			continue
+		}
+		common = true
+		for i != -1 {
+			// Extend the match in both directions.
+			s1, s2, e1, e2 := i, b, i+1, b+1
+			rc := counts[r]
+			for s1 > 0 && s2 > 0 && text1[s1-1] == text2[s2-1] {
+				s1--
+				s2--
+				if rc > 1 {
+					rc = min(rc, counts[text1[s1]])
+				}
+			}
+			for e1 < len(text1) && e2 < len(text2) && text1[e1] == text2[e2] {
+				if rc > 1 {
+					rc = min(rc, counts[text1[e1]])
+				}
+				e1++
+				e2++
+			}
+			if bnext < e2 {
+				bnext = e2
+			}
+			if end1-start1 < e1-s1 || rc < low {
+				start1, end1, start2, end2, low = s1, e1, s2, e2, rc
+			}
+			// Skip occurrences inside the run just found.
+			for i = next[i]; i != -1 && i < e1; i = next[i] {
+			}
+		}
+		b = bnext
+	}
+	if low > histogramMaxChain {
+		return 0, 0, 0, 0, common
+	}
+	return start1, end1, start2, end2, common
+}
@@ -0,0 +1,76 @@
+package diffmatchpatch
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramLCS(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Text1    string
+		Text2    string
+		Expected []int
+		Common   bool
+	}{
+		{"No common lines", "abc", "xyz", []int{0, 0, 0, 0}, false},
+		{"Single line", "abc", "xbz", []int{1, 2, 1, 2}, true},
+		{"Longest run", "abcd", "xbcdy", []int{1, 4, 1, 4}, true},
+		{"Longest run of least common lines", "aabaab", "xaabaay", []int{0, 5, 1, 6}, true},
+	}
+	for i, test := range tests {
+		start1, end1, start2, end2, common := histogramLCS([]rune(test.Text1), []rune(test.Text2))
+		assert.Equal(t, test.Expected, []int{start1, end1, start2, end2}, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		assert.Equal(t, test.Common, common, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+	// Lines occurring too often are not used.
+	text1 := []rune(strings.Repeat("a", histogramMaxChain+1))
+	_, end1, _, _, common := histogramLCS(text1, []rune("a"))
+	assert.Equal(t, 0, end1)
+	assert.True(t, common)
+}
+
+func TestDiffHistogram(t *testing.T) {
+	text1 := "func f4() {\n\tx3()\n\tx4()\n\tx3()\n}\n\n"
+	text2 := "func f3() {\n\tx1()\n\tx4()\n}\n\nfunc f4() {\n\tx1()\n\tx4()\n}\n\n"
+	config := NewDefaultConfig()
+	config.DiffAlgorithm = AlgorithmHistogram
+	// Same as git diff --histogram.
+	assert.Equal(t, "--- a\n+++ b\n@@ -1,6 +1,10 @@\n-func f4() {\n-\tx3()\n+func f3() {\n+\tx1()\n+\tx4()\n+}\n+\n+func f4() {\n+\tx1()\n \tx4()\n-\tx3()\n }\n \n", config.DiffUnified("a", "b", text1, text2))
+	diffs := config.Diff(text1, text2, false)
+	assert.Equal(t, text1, config.DiffText1(diffs))
+	assert.Equal(t, text2, config.DiffText2(diffs))
+	// Only overly common lines in common falls back to Myers.
+	text1 = strings.Repeat("x\n", histogramMaxChain+1)
+	assert.Equal(t, []Diff{{OpEqual, text1}, {OpInsert, "y\n"}}, config.Diff(text1, text1+"y\n", false))
+}
+
+func BenchmarkDiffAlgorithmLargeLines(b *testing.B) {
+	data, _ := ioutil.ReadFile(filepath.Join("testdata", "diff10klinestest.txt"))
+	s1 := string(data)
+	s2 := strings.Replace(strings.Replace(s1, "line no: 5000\n", "", 1), "line no: 70000\n", "line no: 70000\nline no: 5000\n", 1)
+	for _, test := range []struct {
+		Name      string
+		Algorithm Algorithm
+	}{
+		{"Myers", AlgorithmMyers},
+		{"Patience", AlgorithmPatience},
+		{"Histogram", AlgorithmHistogram},
+	} {
+		algorithm := test.Algorithm
+		b.Run(test.Name, func(b *testing.B) {
+			config := NewDefaultConfig()
+			config.DiffTimeout = 0
+			config.DiffAlgorithm = algorithm
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = config.DiffUnified("a", "b", s1, s2)
+			}
+		})
+	}
+}
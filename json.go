@@ -0,0 +1,253 @@
+package diffmatchpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// MarshalText satisfies the encoding.TextMarshaler interface, encoding op as
+// "delete", "equal" or "insert".
+func (op Op) MarshalText() ([]byte, error) {
+	switch op {
+	case OpDelete:
+		return []byte("delete"), nil
+	case OpEqual:
+		return []byte("equal"), nil
+	case OpInsert:
+		return []byte("insert"), nil
+	}
+	return nil, fmt.Errorf("invalid op %d", int(op))
+}
+
+// UnmarshalText satisfies the encoding.TextUnmarshaler interface.
+func (op *Op) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "delete":
+		*op = OpDelete
+	case "equal":
+		*op = OpEqual
+	case "insert":
+		*op = OpInsert
+	default:
+		return fmt.Errorf("invalid op %q", text)
+	}
+	return nil
+}
+
+// MarshalJSON satisfies the json.Marshaler interface, encoding op as a
+// string, as MarshalText.
+func (op Op) MarshalJSON() ([]byte, error) {
+	text, err := op.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.  As well as the
+// strings written by MarshalJSON, the numbers -1, 0 and 1 are accepted, as
+// ops were encoded as numbers before.
+func (op *Op) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		if n < -1 || n > 1 {
+			return fmt.Errorf("invalid op %d", n)
+		}
+		*op = Op(n)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("invalid op %s", data)
+	}
+	return op.UnmarshalText([]byte(text))
+}
+
+// jsonDiff is the JSON encoding of a Diff.
+type jsonDiff struct {
+	Op   *Op    `json:"op"`
+	Text string `json:"text"`
+}
+
+// MarshalJSON satisfies the json.Marshaler interface, encoding d as an
+// object with "op" and "text" fields.  Returns an error if the text is not
+// valid UTF-8, as it could not be decoded as it was.
+func (d Diff) MarshalJSON() ([]byte, error) {
+	if !utf8.ValidString(d.Text) {
+		return nil, fmt.Errorf("invalid UTF-8 text: %q", d.Text)
+	}
+	return json.Marshal(jsonDiff{&d.Op, d.Text})
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.
+func (d *Diff) UnmarshalJSON(data []byte) error {
+	var v jsonDiff
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Op == nil {
+		return errors.New("diff has no op")
+	}
+	*d = Diff{*v.Op, v.Text}
+	return nil
+}
+
+// jsonPatch is the JSON encoding of a Patch.
+type jsonPatch struct {
+	Start1      int    `json:"start1"`
+	Length1     int    `json:"length1"`
+	Start2      int    `json:"start2"`
+	Length2     int    `json:"length2"`
+	RuneStart1  *int   `json:"runeStart1,omitempty"`
+	RuneStart2  *int   `json:"runeStart2,omitempty"`
+	RuneLength1 *int   `json:"runeLength1,omitempty"`
+	RuneLength2 *int   `json:"runeLength2,omitempty"`
+	Diffs       []Diff `json:"diffs"`
+}
+
+// MarshalJSON satisfies the json.Marshaler interface, encoding p as an
+// object with the fields of the patch, in lower camel case, and the lengths
+// of the patch in runes, "runeLength1" and "runeLength2".  Starts and
+// lengths are otherwise in bytes.  The starts in runes depend on the text,
+// and are only encoded by PatchToJSON.
+func (p Patch) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.jsonPatch())
+}
+
+// jsonPatch returns the JSON encoding of p, without its starts in runes.
+func (p Patch) jsonPatch() jsonPatch {
+	runeLength1 := utf8.RuneCountInString(defaultConfig.DiffText1(p.Diffs))
+	runeLength2 := utf8.RuneCountInString(defaultConfig.DiffText2(p.Diffs))
+	diffs := p.Diffs
+	if diffs == nil {
+		diffs = []Diff{}
+	}
+	return jsonPatch{
+		Start1:      p.Start1,
+		Length1:     p.Length1,
+		Start2:      p.Start2,
+		Length2:     p.Length2,
+		RuneLength1: &runeLength1,
+		RuneLength2: &runeLength2,
+		Diffs:       diffs,
+	}
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.  Returns an error
+// if a start is negative, if a start in runes could not be the start in
+// bytes, or if a length does not match the diffs.  The starts and lengths in
+// runes may be left out.
+func (p *Patch) UnmarshalJSON(data []byte) error {
+	var v jsonPatch
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Start1 < 0 || v.Start2 < 0 {
+		return fmt.Errorf("negative patch start %d, %d", v.Start1, v.Start2)
+	}
+	text1, text2 := defaultConfig.DiffText1(v.Diffs), defaultConfig.DiffText2(v.Diffs)
+	switch {
+	case v.Length1 != len(text1):
+		return fmt.Errorf("patch length1 is %d, but the diffs have %d bytes", v.Length1, len(text1))
+	case v.Length2 != len(text2):
+		return fmt.Errorf("patch length2 is %d, but the diffs have %d bytes", v.Length2, len(text2))
+	case v.RuneLength1 != nil && *v.RuneLength1 != utf8.RuneCountInString(text1):
+		return fmt.Errorf("patch runeLength1 is %d, but the diffs have %d runes", *v.RuneLength1, utf8.RuneCountInString(text1))
+	case v.RuneLength2 != nil && *v.RuneLength2 != utf8.RuneCountInString(text2):
+		return fmt.Errorf("patch runeLength2 is %d, but the diffs have %d runes", *v.RuneLength2, utf8.RuneCountInString(text2))
+	case v.RuneStart1 != nil && !runeStartFits(*v.RuneStart1, v.Start1):
+		return fmt.Errorf("patch runeStart1 is %d, but start1 is %d bytes", *v.RuneStart1, v.Start1)
+	case v.RuneStart2 != nil && !runeStartFits(*v.RuneStart2, v.Start2):
+		return fmt.Errorf("patch runeStart2 is %d, but start2 is %d bytes", *v.RuneStart2, v.Start2)
+	}
+	*p = Patch{
+		Diffs:   v.Diffs,
+		Start1:  v.Start1,
+		Start2:  v.Start2,
+		Length1: v.Length1,
+		Length2: v.Length2,
+	}
+	return nil
+}
+
+// runeStartFits returns whether n runes could take start bytes.
+func runeStartFits(n, start int) bool {
+	return n >= 0 && n <= start && start <= n*utf8.UTFMax
+}
+
+// PatchToJSON encodes patches as a JSON array of objects as
+// Patch.MarshalJSON, with the starts of the patches in runes, "runeStart1"
+// and "runeStart2", found from text, the text the patches apply to.  Returns
+// an error if the patches do not apply to text exactly, each after the
+// patches before it, as made by PatchMake.
+func (config *Config) PatchToJSON(patches []Patch, text string) ([]byte, error) {
+	starts, err := config.patchRuneStarts(patches, text)
+	if err != nil {
+		return nil, err
+	}
+	v := make([]jsonPatch, len(patches))
+	for i, p := range patches {
+		v[i] = p.jsonPatch()
+		v[i].RuneStart1, v[i].RuneStart2 = &starts[i][0], &starts[i][1]
+	}
+	return json.Marshal(v)
+}
+
+// PatchFromJSON decodes a JSON array of patches, as encoded by PatchToJSON,
+// that apply to text.  Returns an error if the patches do not apply to text
+// as for PatchToJSON, or if their starts in runes, where given, are not
+// their starts in the text.
+func (config *Config) PatchFromJSON(data []byte, text string) ([]Patch, error) {
+	var patches []Patch
+	if err := json.Unmarshal(data, &patches); err != nil {
+		return nil, err
+	}
+	var v []jsonPatch
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	starts, err := config.patchRuneStarts(patches, text)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range v {
+		switch {
+		case p.RuneStart1 != nil && *p.RuneStart1 != starts[i][0]:
+			return nil, fmt.Errorf("patch %d runeStart1 is %d, but start1 is at rune %d of the text", i, *p.RuneStart1, starts[i][0])
+		case p.RuneStart2 != nil && *p.RuneStart2 != starts[i][1]:
+			return nil, fmt.Errorf("patch %d runeStart2 is %d, but start2 is at rune %d of the patched text", i, *p.RuneStart2, starts[i][1])
+		}
+	}
+	if patches == nil {
+		patches = []Patch{}
+	}
+	return patches, nil
+}
+
+// patchRuneStarts returns the starts in runes of patches to text.  As made
+// by PatchMake, each patch applies to text with the patches before it
+// applied, start1 being in the text before the patch and start2 after.
+func (config *Config) patchRuneStarts(patches []Patch, text string) ([][2]int, error) {
+	starts := make([][2]int, len(patches))
+	for i, p := range patches {
+		text1 := config.DiffText1(p.Diffs)
+		switch {
+		case p.Start1+len(text1) > len(text) || text[p.Start1:p.Start1+len(text1)] != text1:
+			return nil, fmt.Errorf("patch %d does not match the text at %d", i, p.Start1)
+		case p.Start1 < len(text) && !utf8.RuneStart(text[p.Start1]):
+			return nil, fmt.Errorf("patch %d start1 %d is within a rune", i, p.Start1)
+		}
+		starts[i][0] = utf8.RuneCountInString(text[:p.Start1])
+		text = text[:p.Start1] + config.DiffText2(p.Diffs) + text[p.Start1+len(text1):]
+		if p.Start2 > len(text) || p.Start2 < len(text) && !utf8.RuneStart(text[p.Start2]) {
+			return nil, fmt.Errorf("patch %d start2 %d is not at a rune of the patched text", i, p.Start2)
+		}
+		starts[i][1] = utf8.RuneCountInString(text[:p.Start2])
+	}
+	return starts, nil
+}
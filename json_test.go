@@ -0,0 +1,235 @@
+package diffmatchpatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpMarshal(t *testing.T) {
+	tests := []struct {
+		Op   Op
+		Text string
+	}{
+		{OpDelete, "delete"},
+		{OpEqual, "equal"},
+		{OpInsert, "insert"},
+	}
+	for i, test := range tests {
+		text, err := test.Op.MarshalText()
+		assert.Nil(t, err, fmt.Sprintf("Test case #%d, %s", i, test.Text))
+		assert.Equal(t, test.Text, string(text), fmt.Sprintf("Test case #%d, %s", i, test.Text))
+		data, err := json.Marshal(test.Op)
+		assert.Nil(t, err, fmt.Sprintf("Test case #%d, %s", i, test.Text))
+		assert.Equal(t, `"`+test.Text+`"`, string(data), fmt.Sprintf("Test case #%d, %s", i, test.Text))
+		var op Op
+		assert.Nil(t, json.Unmarshal(data, &op), fmt.Sprintf("Test case #%d, %s", i, test.Text))
+		assert.Equal(t, test.Op, op, fmt.Sprintf("Test case #%d, %s", i, test.Text))
+		// Ops used to be encoded as numbers.
+		op = 5
+		assert.Nil(t, json.Unmarshal([]byte(fmt.Sprint(int(test.Op))), &op), fmt.Sprintf("Test case #%d, %s", i, test.Text))
+		assert.Equal(t, test.Op, op, fmt.Sprintf("Test case #%d, %s", i, test.Text))
+	}
+	_, err := Op(2).MarshalText()
+	assert.EqualError(t, err, "invalid op 2")
+	_, err = json.Marshal(Op(-2))
+	assert.EqualError(t, err, "json: error calling MarshalJSON for type *diffmatchpatch.Op: invalid op -2")
+	var op Op
+	assert.EqualError(t, op.UnmarshalText([]byte("Insert")), `invalid op "Insert"`)
+	assert.EqualError(t, json.Unmarshal([]byte(`"keep"`), &op), `invalid op "keep"`)
+	assert.EqualError(t, json.Unmarshal([]byte(`2`), &op), "invalid op 2")
+	assert.EqualError(t, json.Unmarshal([]byte(`true`), &op), "invalid op true")
+	// Null leaves the op as it is.
+	op = OpInsert
+	assert.Nil(t, json.Unmarshal([]byte(`null`), &op))
+	assert.Equal(t, OpInsert, op)
+}
+
+func TestDiffJSON(t *testing.T) {
+	diffs := []Diff{{OpEqual, "a\n"}, {OpDelete, "日本"}, {OpInsert, "\"x\"\ty"}}
+	data, err := json.Marshal(diffs)
+	assert.Nil(t, err)
+	assert.Equal(t, `[{"op":"equal","text":"a\n"},{"op":"delete","text":"日本"},{"op":"insert","text":"\"x\"\ty"}]`, string(data))
+	var actual []Diff
+	assert.Nil(t, json.Unmarshal(data, &actual))
+	assert.Equal(t, diffs, actual)
+	tests := []struct {
+		Name  string
+		JSON  string
+		Error string
+	}{
+		{"No op", `{"text":"a"}`, "diff has no op"},
+		{"Null op", `{"op":null,"text":"a"}`, "diff has no op"},
+		{"Bad op", `{"op":"keep","text":"a"}`, `invalid op "keep"`},
+		{"Bad text", `{"op":"equal","text":1}`, "json: cannot unmarshal number into Go struct field jsonDiff.text of type string"},
+	}
+	for i, test := range tests {
+		var d Diff
+		assert.EqualError(t, json.Unmarshal([]byte(test.JSON), &d), test.Error, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+	_, err = json.Marshal(Diff{OpEqual, "\xff"})
+	assert.EqualError(t, err, `json: error calling MarshalJSON for type *diffmatchpatch.Diff: invalid UTF-8 text: "\xff"`)
+}
+
+func TestPatchJSON(t *testing.T) {
+	config := NewDefaultConfig()
+	patches := config.PatchMake("The quick brown fox jumps.", "The quick 茶色 fox jumped.")
+	data, err := json.Marshal(patches)
+	assert.Nil(t, err)
+	var actual []Patch
+	assert.Nil(t, json.Unmarshal(data, &actual))
+	assert.Equal(t, patches, actual)
+	data, err = json.Marshal(Patch{
+		Diffs:   []Diff{{OpEqual, "ab"}, {OpDelete, "c"}, {OpInsert, "日"}},
+		Start1:  3,
+		Start2:  4,
+		Length1: 3,
+		Length2: 5,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"start1":3,"length1":3,"start2":4,"length2":5,"runeLength1":3,"runeLength2":3,`+
+		`"diffs":[{"op":"equal","text":"ab"},{"op":"delete","text":"c"},{"op":"insert","text":"日"}]}`, string(data))
+	data, err = json.Marshal(Patch{})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"start1":0,"length1":0,"start2":0,"length2":0,"runeLength1":0,"runeLength2":0,"diffs":[]}`, string(data))
+	tests := []struct {
+		Name     string
+		JSON     string
+		Expected Patch
+		Error    string
+	}{
+		{
+			"No rune lengths",
+			`{"start1":1,"length1":1,"start2":2,"length2":3,"diffs":[{"op":"delete","text":"a"},{"op":"insert","text":"日"}]}`,
+			Patch{Diffs: []Diff{{OpDelete, "a"}, {OpInsert, "日"}}, Start1: 1, Start2: 2, Length1: 1, Length2: 3},
+			"",
+		},
+		{"Negative start", `{"start1":-1,"diffs":[]}`, Patch{}, "negative patch start -1, 0"},
+		{"Bad length1", `{"length1":2,"length2":1,"diffs":[{"op":"equal","text":"a"}]}`, Patch{}, "patch length1 is 2, but the diffs have 1 bytes"},
+		{"Bad length2", `{"length1":1,"length2":0,"diffs":[{"op":"equal","text":"a"}]}`, Patch{}, "patch length2 is 0, but the diffs have 1 bytes"},
+		{
+			"Bad runeLength1",
+			`{"length1":3,"length2":3,"runeLength1":3,"diffs":[{"op":"equal","text":"日"}]}`,
+			Patch{},
+			"patch runeLength1 is 3, but the diffs have 1 runes",
+		},
+		{
+			"Bad runeLength2",
+			`{"length1":3,"length2":3,"runeLength2":3,"diffs":[{"op":"equal","text":"日"}]}`,
+			Patch{},
+			"patch runeLength2 is 3, but the diffs have 1 runes",
+		},
+		{
+			"Rune starts",
+			`{"start1":6,"length1":1,"start2":3,"length2":1,"runeStart1":2,"runeStart2":1,"diffs":[{"op":"equal","text":"a"}]}`,
+			Patch{Diffs: []Diff{{OpEqual, "a"}}, Start1: 6, Start2: 3, Length1: 1, Length2: 1},
+			"",
+		},
+		{"Bad runeStart1", `{"start1":2,"runeStart1":3,"diffs":[]}`, Patch{}, "patch runeStart1 is 3, but start1 is 2 bytes"},
+		{"Bad runeStart2", `{"start2":9,"runeStart2":2,"diffs":[]}`, Patch{}, "patch runeStart2 is 2, but start2 is 9 bytes"},
+		{"Negative runeStart1", `{"runeStart1":-1,"diffs":[]}`, Patch{}, "patch runeStart1 is -1, but start1 is 0 bytes"},
+		{"Bad diff", `{"diffs":[{"text":"a"}]}`, Patch{}, "diff has no op"},
+	}
+	for i, test := range tests {
+		var p Patch
+		err := json.Unmarshal([]byte(test.JSON), &p)
+		if test.Error == "" {
+			assert.Nil(t, err, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+			assert.Equal(t, test.Expected, p, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		} else {
+			assert.EqualError(t, err, test.Error, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		}
+	}
+}
+
+func TestPatchToJSON(t *testing.T) {
+	config := NewDefaultConfig()
+	text1 := "日本語のテキストです。The quick brown fox jumps over the lazy dog."
+	text2 := "日本のテキストです。The quick brown fox jumped over the lazy cat!"
+	patches := config.PatchMakeFromTexts(text1, text2)
+	data, err := config.PatchToJSON(patches, text1)
+	assert.Nil(t, err)
+	var v []struct {
+		RuneStart1 int `json:"runeStart1"`
+		RuneStart2 int `json:"runeStart2"`
+	}
+	assert.Nil(t, json.Unmarshal(data, &v))
+	assert.Equal(t, len(patches), len(v))
+	// Each patch applies to the text with the patches before it applied.
+	text := text1
+	for i, p := range patches {
+		msg := fmt.Sprintf("Patch #%d", i)
+		assert.Equal(t, text[p.Start1:], string([]rune(text)[v[i].RuneStart1:]), msg)
+		text = text[:p.Start1] + config.DiffText2(p.Diffs) + text[p.Start1+p.Length1:]
+		assert.Equal(t, text[p.Start2:], string([]rune(text)[v[i].RuneStart2:]), msg)
+	}
+	assert.Equal(t, text2, text)
+	actual, err := config.PatchFromJSON(data, text1)
+	assert.Nil(t, err)
+	assert.Equal(t, patches, actual)
+	// Plain patches have no rune starts to check.
+	data, err = json.Marshal(patches)
+	assert.Nil(t, err)
+	actual, err = config.PatchFromJSON(data, text1)
+	assert.Nil(t, err)
+	assert.Equal(t, patches, actual)
+
+	data, err = config.PatchToJSON(nil, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "[]", string(data))
+	actual, err = config.PatchFromJSON(data, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []Patch{}, actual)
+
+	tests := []struct {
+		Name  string
+		JSON  string
+		Error string
+	}{
+		{
+			"Wrong runeStart1",
+			`[{"start1":6,"length1":3,"start2":6,"length2":0,"runeStart1":3,"diffs":[{"op":"delete","text":"語"}]}]`,
+			"patch 0 runeStart1 is 3, but start1 is at rune 2 of the text",
+		},
+		{
+			"Wrong runeStart2",
+			`[{"start1":6,"length1":3,"start2":6,"length2":0,"runeStart2":3,"diffs":[{"op":"delete","text":"語"}]}]`,
+			"patch 0 runeStart2 is 3, but start2 is at rune 2 of the patched text",
+		},
+		{
+			"Not matching",
+			`[{"start1":3,"length1":3,"start2":3,"length2":0,"diffs":[{"op":"delete","text":"語"}]}]`,
+			"patch 0 does not match the text at 3",
+		},
+		{
+			"Past the end",
+			`[{"start1":500,"length1":0,"start2":500,"length2":1,"diffs":[{"op":"insert","text":"x"}]}]`,
+			"patch 0 does not match the text at 500",
+		},
+		{
+			"Start1 within a rune",
+			`[{"start1":1,"length1":0,"start2":1,"length2":1,"diffs":[{"op":"insert","text":"x"}]}]`,
+			"patch 0 start1 1 is within a rune",
+		},
+		{
+			"Start2 within a rune",
+			`[{"start1":6,"length1":3,"start2":7,"length2":0,"diffs":[{"op":"delete","text":"語"}]}]`,
+			"patch 0 start2 7 is not at a rune of the patched text",
+		},
+		{
+			"Second patch applies after the first",
+			`[{"start1":6,"length1":3,"start2":6,"length2":0,"diffs":[{"op":"delete","text":"語"}]},` +
+				`{"start1":9,"length1":3,"start2":9,"length2":3,"diffs":[{"op":"equal","text":"の"}]}]`,
+			"patch 1 does not match the text at 9",
+		},
+		{"Invalid patch", `[{"start1":-1,"diffs":[]}]`, "negative patch start -1, 0"},
+	}
+	for i, test := range tests {
+		_, err := config.PatchFromJSON([]byte(test.JSON), text1)
+		assert.EqualError(t, err, test.Error, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+	_, err = config.PatchToJSON(patches, "")
+	assert.EqualError(t, err, "patch 0 does not match the text at 0")
+}
@@ -1,12 +1,36 @@
 package diffmatchpatch
 
 import (
+	"context"
 	"math"
+	"math/bits"
+	"unicode/utf8"
 )
 
 // Match locates the best instance of 'pattern' in 'text' near 'loc'. Returns
 // -1 if no match found.
+//
+// Both loc and the returned location are byte offsets into text. Matching is
+// performed on runes, so the returned location never splits a multi-byte
+// rune. See MatchRunes for the rune offset equivalent.
 func (config *Config) Match(text, pattern string, loc int) int {
+	return config.match(context.Background(), text, pattern, loc)
+}
+
+// MatchContext locates the best instance of 'pattern' in 'text' near 'loc',
+// stopping early and returning ctx.Err() when ctx is done. Returns -1 if no
+// match found.
+//
+// Both loc and the returned location are byte offsets into text.
+func (config *Config) MatchContext(ctx context.Context, text, pattern string, loc int) (int, error) {
+	loc = config.match(ctx, text, pattern, loc)
+	if err := ctx.Err(); err != nil {
+		return -1, err
+	}
+	return loc, nil
+}
+
+func (config *Config) match(ctx context.Context, text, pattern string, loc int) int {
 	// Check for null inputs not needed since null can't be passed in C#.
 	loc = max(0, min(loc, len(text)))
 	if text == pattern {
@@ -20,24 +44,72 @@ func (config *Config) Match(text, pattern string, loc int) int {
 		return loc
 	}
 	// Do a fuzzy compare.
-	return config.MatchBitap(text, pattern, loc)
+	return config.matchBitap(ctx, text, pattern, loc)
+}
+
+// MatchRunes locates the best instance of 'pattern' in 'text' near 'loc'.
+// Returns -1 if no match found.
+//
+// Both loc and the returned location are rune offsets into text.
+func (config *Config) MatchRunes(text, pattern []rune, loc int) int {
+	loc = max(0, min(loc, len(text)))
+	if runesEqual(text, pattern) {
+		// Shortcut (potentially not guaranteed by the algorithm)
+		return 0
+	} else if len(text) == 0 {
+		// Nothing to match.
+		return -1
+	} else if loc+len(pattern) <= len(text) && runesEqual(text[loc:loc+len(pattern)], pattern) {
+		// Perfect match at the perfect spot!  (Includes case of null pattern)
+		return loc
+	}
+	// Do a fuzzy compare.
+	return config.matchBitapRunes(context.Background(), text, pattern, loc)
 }
 
 // MatchBitap locates the best instance of 'pattern' in 'text' near 'loc' using
 // the Bitap algorithm.  Returns -1 if no match was found.
+//
+// Both loc and the returned location are byte offsets into text.
 func (config *Config) MatchBitap(text, pattern string, loc int) int {
+	return config.matchBitap(context.Background(), text, pattern, loc)
+}
+
+func (config *Config) matchBitap(ctx context.Context, text, pattern string, loc int) int {
+	i := config.matchBitapRunes(ctx, []rune(text), []rune(pattern), byteToRuneOffset(text, loc))
+	if i == -1 {
+		return -1
+	}
+	return runeToByteOffset(text, i)
+}
+
+// MatchBitapRunes locates the best instance of 'pattern' in 'text' near 'loc'
+// using the Bitap algorithm.  Returns -1 if no match was found.
+//
+// Both loc and the returned location are rune offsets into text.
+//
+// Patterns longer than a machine word are matched using multi-word bit
+// vectors, so there is no limit on the pattern length.
+func (config *Config) MatchBitapRunes(text, pattern []rune, loc int) int {
+	return config.matchBitapRunes(context.Background(), text, pattern, loc)
+}
+
+func (config *Config) matchBitapRunes(ctx context.Context, text, pattern []rune, loc int) int {
+	if len(pattern) > bits.UintSize {
+		return config.matchBitapLong(ctx, text, pattern, loc)
+	}
 	// Initialise the alphabet.
-	s := config.MatchAlphabet(pattern)
+	s := config.MatchAlphabetRunes(pattern)
 	// Highest score beyond which we give up.
 	scoreThreshold := config.MatchThreshold
 	// Is there a nearby exact match? (speedup)
-	bestLoc := indexOf(text, pattern, loc)
+	bestLoc := runesIndexOf(text, pattern, loc)
 	if bestLoc != -1 {
-		scoreThreshold = math.Min(config.matchBitapScore(0, bestLoc, loc, pattern), scoreThreshold)
+		scoreThreshold = math.Min(config.matchBitapScore(0, bestLoc, loc, len(pattern)), scoreThreshold)
 		// What about in the other direction? (speedup)
-		bestLoc = lastIndexOf(text, pattern, loc+len(pattern))
+		bestLoc = runesLastIndexOf(text, pattern, loc+len(pattern))
 		if bestLoc != -1 {
-			scoreThreshold = math.Min(config.matchBitapScore(0, bestLoc, loc, pattern), scoreThreshold)
+			scoreThreshold = math.Min(config.matchBitapScore(0, bestLoc, loc, len(pattern)), scoreThreshold)
 		}
 	}
 	// Initialise the bit arrays.
@@ -47,13 +119,16 @@ func (config *Config) MatchBitap(text, pattern string, loc int) int {
 	binMax := len(pattern) + len(text)
 	lastRd := []int{}
 	for d := 0; d < len(pattern); d++ {
+		if ctx.Err() != nil {
+			return -1
+		}
 		// Scan for the best match; each iteration allows for one more error.
 		// Run a binary search to determine how far from 'loc' we can stray at
 		// this error level.
 		binMin = 0
 		binMid = binMax
 		for binMin < binMid {
-			if config.matchBitapScore(d, loc+binMid, loc, pattern) <= scoreThreshold {
+			if config.matchBitapScore(d, loc+binMid, loc, len(pattern)) <= scoreThreshold {
 				binMin = binMid
 			} else {
 				binMax = binMid
@@ -71,8 +146,6 @@ func (config *Config) MatchBitap(text, pattern string, loc int) int {
 			if len(text) <= j-1 {
 				// Out of range.
 				charMatch = 0
-			} else if _, ok := s[text[j-1]]; !ok {
-				charMatch = 0
 			} else {
 				charMatch = s[text[j-1]]
 			}
@@ -84,7 +157,7 @@ func (config *Config) MatchBitap(text, pattern string, loc int) int {
 				rd[j] = ((rd[j+1]<<1)|1)&charMatch | (((lastRd[j+1] | lastRd[j]) << 1) | 1) | lastRd[j+1]
 			}
 			if (rd[j] & matchmask) != 0 {
-				score := config.matchBitapScore(d, j-1, loc, pattern)
+				score := config.matchBitapScore(d, j-1, loc, len(pattern))
 				// This match will almost certainly be better than any existing
 				// match.  But check anyway.
 				if score <= scoreThreshold {
@@ -101,7 +174,7 @@ func (config *Config) MatchBitap(text, pattern string, loc int) int {
 				}
 			}
 		}
-		if config.matchBitapScore(d+1, loc, loc, pattern) > scoreThreshold {
+		if config.matchBitapScore(d+1, loc, loc, len(pattern)) > scoreThreshold {
 			// No hope for a (better) match at greater error levels.
 			break
 		}
@@ -110,9 +183,124 @@ func (config *Config) MatchBitap(text, pattern string, loc int) int {
 	return bestLoc
 }
 
-// matchBitapScore computes and returns the score for a match with e errors and x location.
-func (config *Config) matchBitapScore(e, x, loc int, pattern string) float64 {
-	accuracy := float64(e) / float64(len(pattern))
+// matchBitapLong is the multi-word equivalent of MatchBitapRunes, used for
+// patterns longer than a machine word.
+func (config *Config) matchBitapLong(ctx context.Context, text, pattern []rune, loc int) int {
+	// Initialise the alphabet.
+	s := matchAlphabetBitset(pattern)
+	// Highest score beyond which we give up.
+	scoreThreshold := config.MatchThreshold
+	// Is there a nearby exact match? (speedup)
+	bestLoc := runesIndexOf(text, pattern, loc)
+	if bestLoc != -1 {
+		scoreThreshold = math.Min(config.matchBitapScore(0, bestLoc, loc, len(pattern)), scoreThreshold)
+		// What about in the other direction? (speedup)
+		bestLoc = runesLastIndexOf(text, pattern, loc+len(pattern))
+		if bestLoc != -1 {
+			scoreThreshold = math.Min(config.matchBitapScore(0, bestLoc, loc, len(pattern)), scoreThreshold)
+		}
+	}
+	// Initialise the bit arrays.
+	w := bitsetWords(len(pattern))
+	matchbit := len(pattern) - 1
+	empty := make(bitset, w)
+	bestLoc = -1
+	var binMin, binMid int
+	binMax := len(pattern) + len(text)
+	// rd and lastRd hold the bit vectors of this and the previous error
+	// level, w words each, for positions from base on.  They are allocated
+	// for the widest level, the first, and reused.
+	var rd, lastRd bitset
+	base := 0
+	for d := 0; d < len(pattern); d++ {
+		if ctx.Err() != nil {
+			return -1
+		}
+		// Scan for the best match; each iteration allows for one more error.
+		// Run a binary search to determine how far from 'loc' we can stray at
+		// this error level.
+		binMin = 0
+		binMid = binMax
+		for binMin < binMid {
+			if config.matchBitapScore(d, loc+binMid, loc, len(pattern)) <= scoreThreshold {
+				binMin = binMid
+			} else {
+				binMax = binMid
+			}
+			binMid = (binMax-binMin)/2 + binMin
+		}
+		// Use the result from this iteration as the maximum for the next.
+		binMax = binMid
+		start := max(1, loc-binMid+1)
+		finish := min(loc+binMid, len(text)) + len(pattern)
+		if d == 0 {
+			// No position before base is reached, even when start moves back
+			// towards loc.
+			base = max(0, loc-binMid-len(pattern))
+			rd, lastRd = make(bitset, (finish+2-base)*w), make(bitset, (finish+2-base)*w)
+		} else {
+			rd, lastRd = lastRd, rd
+			for k := range rd {
+				rd[k] = 0
+			}
+		}
+		rd.at(finish+1-base, w).setLow(d)
+		for j := finish; j >= start; j-- {
+			charMatch := empty
+			if j-1 < len(text) {
+				if m, ok := s[text[j-1]]; ok {
+					charMatch = m
+				}
+			}
+			cur, next := rd.at(j-base, w), rd.at(j+1-base, w)
+			if d == 0 {
+				// First pass: exact match.
+				cur.shiftOr(next)
+				cur.and(charMatch)
+			} else {
+				// Subsequent passes: fuzzy match.
+				lastCur, lastNext := lastRd.at(j-base, w), lastRd.at(j+1-base, w)
+				cur.shiftOr(next)
+				cur.and(charMatch)
+				for k := range cur {
+					v := lastNext[k] | lastCur[k]
+					carry := uint64(1)
+					if k > 0 {
+						carry = (lastNext[k-1] | lastCur[k-1]) >> 63
+					}
+					cur[k] |= v<<1 | carry | lastNext[k]
+				}
+			}
+			if cur.has(matchbit) {
+				score := config.matchBitapScore(d, j-1, loc, len(pattern))
+				// This match will almost certainly be better than any existing
+				// match.  But check anyway.
+				if score <= scoreThreshold {
+					// Told you so.
+					scoreThreshold = score
+					bestLoc = j - 1
+					if bestLoc > loc {
+						// When passing loc, don't exceed our current distance from loc.
+						start = max(1, 2*loc-bestLoc)
+					} else {
+						// Already passed loc, downhill from here on in.
+						break
+					}
+				}
+			}
+		}
+		if config.matchBitapScore(d+1, loc, loc, len(pattern)) > scoreThreshold {
+			// No hope for a (better) match at greater error levels.
+			break
+		}
+	}
+	return bestLoc
+}
+
+// matchBitapScore computes and returns the score for a match with e errors
+// and x location, for a pattern of n characters.
+func (config *Config) matchBitapScore(e, x, loc, n int) float64 {
+	accuracy := float64(e) / float64(n)
 	proximity := math.Abs(float64(loc - x))
 	if config.MatchDistance == 0 {
 		// Dodge divide by zero error.
@@ -125,6 +313,9 @@ func (config *Config) matchBitapScore(e, x, loc int, pattern string) float64 {
 }
 
 // MatchAlphabet initialises the alphabet for the Bitap algorithm.
+//
+// The alphabet is keyed by byte. See MatchAlphabetRunes for the alphabet used
+// by MatchBitap.
 func (config *Config) MatchAlphabet(pattern string) map[byte]int {
 	s := map[byte]int{}
 	charPattern := []byte(pattern)
@@ -142,3 +333,96 @@ func (config *Config) MatchAlphabet(pattern string) map[byte]int {
 	}
 	return s
 }
+
+// MatchAlphabetRunes initialises the rune alphabet for the Bitap algorithm.
+func (config *Config) MatchAlphabetRunes(pattern []rune) map[rune]int {
+	s := make(map[rune]int, len(pattern))
+	for i, c := range pattern {
+		s[c] |= 1 << uint(len(pattern)-i-1)
+	}
+	return s
+}
+
+// matchAlphabetBitset initialises the multi-word alphabet for the Bitap
+// algorithm.
+func matchAlphabetBitset(pattern []rune) map[rune]bitset {
+	w := bitsetWords(len(pattern))
+	s := make(map[rune]bitset)
+	for i, c := range pattern {
+		b, ok := s[c]
+		if !ok {
+			b = make(bitset, w)
+			s[c] = b
+		}
+		b.set(len(pattern) - i - 1)
+	}
+	return s
+}
+
+// bitset is a multi-word bit vector.
+type bitset []uint64
+
+// bitsetWords returns the number of words needed to hold n bits.
+func bitsetWords(n int) int {
+	return (n + 63) / 64
+}
+
+// at returns the i'th w word wide bit vector in b.
+func (b bitset) at(i, w int) bitset {
+	return b[i*w : (i+1)*w : (i+1)*w]
+}
+
+// set sets bit i.
+func (b bitset) set(i int) {
+	b[i/64] |= 1 << uint(i%64)
+}
+
+// has returns whether bit i is set.
+func (b bitset) has(i int) bool {
+	return b[i/64]&(1<<uint(i%64)) != 0
+}
+
+// setLow sets the low n bits, equivalent to (1 << n) - 1.
+func (b bitset) setLow(n int) {
+	for i := 0; i < n; i++ {
+		b.set(i)
+	}
+}
+
+// shiftOr sets b to (v << 1) | 1.
+func (b bitset) shiftOr(v bitset) {
+	carry := uint64(1)
+	for k, x := range v {
+		b[k] = x<<1 | carry
+		carry = x >> 63
+	}
+}
+
+// and sets b to b & v.
+func (b bitset) and(v bitset) {
+	for k := range b {
+		b[k] &= v[k]
+	}
+}
+
+// byteToRuneOffset converts the byte offset i in s to a rune offset. Offsets
+// falling inside a multi-byte rune are moved back to the start of the rune.
+func byteToRuneOffset(s string, i int) int {
+	if i <= 0 {
+		return i
+	}
+	if i >= len(s) {
+		return utf8.RuneCountInString(s) + i - len(s)
+	}
+	return utf8.RuneCountInString(s[:runeStart(s, i)])
+}
+
+// runeToByteOffset converts the rune offset n in s to a byte offset.
+func runeToByteOffset(s string, n int) int {
+	i := 0
+	for ; n > 0 && i < len(s); n-- {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return i
+}
@@ -1,7 +1,9 @@
 package diffmatchpatch
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -65,6 +67,15 @@ func TestMatchBitap(t *testing.T) {
 		{"Distance test #2", "abcdefghijklmnopqrstuvwxyz", "abcdxxefg", 1, 10, 0.5, 0},
 		// Loose location.
 		{"Distance test #3", "abcdefghijklmnopqrstuvwxyz", "abcdefg", 24, 1000, 0.5, 0},
+		// Byte offsets for multi-byte runes.
+		{"Unicode exact match", "абвгдеёжзий", "еёж", 10, 100, 0.5, 10},
+		{"Unicode fuzzy match", "абвгдеёжзий", "деxжз", 0, 100, 0.5, 8},
+		{"Unicode split rune location", "абвгдеёжзий", "еёж", 11, 100, 0.5, 10},
+		// Patterns longer than a machine word.
+		{"Long exact match", strings.Repeat("abcdefghij", 20), strings.Repeat("abcdefghij", 10), 100, 1000, 0.5, 100},
+		{"Long fuzzy match", strings.Repeat("0123456789", 10) + strings.Repeat("abcdefghij", 10) + strings.Repeat("0123456789", 10), strings.Repeat("abcdexghij", 10), 90, 1000, 0.5, 100},
+		{"Long no match", strings.Repeat("0123456789", 30), strings.Repeat("abcdefghij", 10), 100, 1000, 0.5, -1},
+		{"Long fuzzy match far into a long text", strings.Repeat("0123456789", 100000) + strings.Repeat("abcdefghij", 500), strings.Repeat("abcdefghij", 499) + "abcdexghij", 1000000, 1000, 0.5, 1000000},
 	}
 	for i, test := range tests {
 		config := NewDefaultConfig()
@@ -92,6 +103,8 @@ func TestMatch(t *testing.T) {
 		{"Oversized pattern", "abcdef", "abcdefy", 0, 0.5, 0},
 
 		{"Complex match", "I am the very model of a modern major general.", " that berry ", 5, 0.7, 4},
+		{"Unicode beyond end match", "абвгде", "гдеy", 8, 0.5, 6},
+		{"Unicode complex match", "Я помню чудное мгновенье: передо мной явилась ты.", " чуднуе мгнов ", 9, 0.7, 13},
 	}
 	for i, test := range tests {
 		config := NewDefaultConfig()
@@ -100,3 +113,109 @@ func TestMatch(t *testing.T) {
 		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
 	}
 }
+
+func TestMatchAlphabetRunes(t *testing.T) {
+	tests := []struct {
+		Pattern  string
+		Expected map[rune]int
+	}{
+		{
+			Pattern: "abc",
+			Expected: map[rune]int{
+				'a': 4,
+				'b': 2,
+				'c': 1,
+			},
+		},
+		{
+			Pattern: "абвааб",
+			Expected: map[rune]int{
+				'а': 38,
+				'б': 17,
+				'в': 8,
+			},
+		},
+		{
+			Pattern: "日本語😀",
+			Expected: map[rune]int{
+				'日': 8,
+				'本': 4,
+				'語': 2,
+				'😀': 1,
+			},
+		},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		actual := config.MatchAlphabetRunes([]rune(test.Pattern))
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %#v", i, test))
+	}
+}
+
+func TestMatchBitapRunes(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Text      string
+		Pattern   string
+		Location  int
+		Distance  int
+		Threshold float64
+		Expected  int
+	}{
+		{"Exact match #1", "абвгдеёжзий", "еёж", 5, 100, 0.5, 5},
+		{"Exact match #2", "абвгдеёжзий", "еёж", 0, 100, 0.5, 5},
+		{"Fuzzy match #1", "абвгдеёжзий", "деxжз", 0, 100, 0.5, 4},
+		{"Fuzzy match #2", "абвгдеёжзий", "вгдеxyжзий", 5, 100, 0.5, 2},
+		{"Fuzzy match #3", "абвгдеёжзий", "бxy", 1, 100, 0.5, -1},
+		{"Before start match", "日本語の文章", "xx日本語", 4, 100, 0.5, 0},
+		{"Beyond end match", "日本語の文章", "の文章yy", 4, 100, 0.5, 3},
+		{"Threshold #1", "абвгдеёжзий", "деxyзи", 1, 100, 0.4, 4},
+		{"Threshold #2", "абвгдеёжзий", "деxyзи", 1, 100, 0.3, -1},
+		{"Multiple select #1", "😀😁😂😃😄xyz😀😁😂😃😄", "😀😁😂😂😃😄", 3, 100, 0.5, 0},
+		{"Multiple select #2", "😀😁😂😃😄xyz😀😁😂😃😄", "😀😁😂😂😃😄", 5, 100, 0.5, 8},
+	}
+	for i, test := range tests {
+		config := NewDefaultConfig()
+		config.MatchDistance = test.Distance
+		config.MatchThreshold = test.Threshold
+		actual := config.MatchBitapRunes([]rune(test.Text), []rune(test.Pattern), test.Location)
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
+
+func TestMatchRunes(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Text1     string
+		Text2     string
+		Location  int
+		Threshold float64
+		Expected  int
+	}{
+		{"Equality", "абвгде", "абвгде", 1000, 0.5, 0},
+		{"Null text", "", "абвгде", 1, 0.5, -1},
+		{"Null pattern", "абвгде", "", 3, 0.5, 3},
+		{"Exact match", "абвгде", "гд", 3, 0.5, 3},
+		{"Beyond end match", "абвгде", "гдеy", 4, 0.5, 3},
+		{"Oversized pattern", "абвгде", "абвгдеy", 0, 0.5, 0},
+		{"Complex match", "Я помню чудное мгновенье: передо мной явилась ты.", " чуднуе мгнов ", 5, 0.7, 7},
+	}
+	for i, test := range tests {
+		config := NewDefaultConfig()
+		config.MatchThreshold = test.Threshold
+		actual := config.MatchRunes([]rune(test.Text1), []rune(test.Text2), test.Location)
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
+
+func TestMatchContext(t *testing.T) {
+	config := NewDefaultConfig()
+	loc, err := config.MatchContext(context.Background(), "abcdefghijk", "efxhi", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, loc)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	loc, err = config.MatchContext(ctx, "abcdefghijk", "efxhi", 0)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, -1, loc)
+}
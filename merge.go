@@ -0,0 +1,216 @@
+package diffmatchpatch
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Conflict is a region of a three-way merge that ours and theirs changed in
+// different ways.
+type Conflict struct {
+	// Start and End are the byte offsets of the region in the merged text.
+	Start int
+	End   int
+	// Base, Ours and Theirs are the region's text in each version.
+	Base   string
+	Ours   string
+	Theirs string
+}
+
+// mergeEdit replaces base[start:end] with text.
+type mergeEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+// mergeCluster is a run of overlapping edits from both sides of a merge,
+// covering base[start:end].
+type mergeCluster struct {
+	start  int
+	end    int
+	ours   []mergeEdit
+	theirs []mergeEdit
+}
+
+// Merge3 merges the changes made by ours and theirs to base.  Changes that do
+// not overlap are interleaved, as are identical changes made by both sides.
+// Where both sides changed the same region in different ways, the region is
+// widened to whole lines, ours is used in the merged text, and a Conflict is
+// returned.
+func (config *Config) Merge3(base, ours, theirs string) (string, []Conflict) {
+	return config.merge3(base, ours, theirs, false)
+}
+
+// Merge3Markers is like Merge3, but writes each conflict to the merged text
+// with git-style markers:
+//
+//	<<<<<<< ours
+//	ours text
+//	=======
+//	theirs text
+//	>>>>>>> theirs
+func (config *Config) Merge3Markers(base, ours, theirs string) (string, []Conflict) {
+	return config.merge3(base, ours, theirs, true)
+}
+
+func (config *Config) merge3(base, ours, theirs string, markers bool) (string, []Conflict) {
+	clusters := mergeClusters(base, config.mergeEdits(base, ours), config.mergeEdits(base, theirs))
+	var buf bytes.Buffer
+	conflicts := []Conflict{}
+	pos := 0
+	for _, c := range clusters {
+		_, _ = buf.WriteString(base[pos:c.start])
+		text1, text2 := c.apply(base, c.ours), c.apply(base, c.theirs)
+		switch {
+		case len(c.theirs) == 0:
+			_, _ = buf.WriteString(text1)
+		case len(c.ours) == 0:
+			_, _ = buf.WriteString(text2)
+		case text1 == text2:
+			_, _ = buf.WriteString(text1)
+		default:
+			conflict := Conflict{Start: buf.Len(), Base: base[c.start:c.end], Ours: text1, Theirs: text2}
+			if markers {
+				_, _ = buf.WriteString("<<<<<<< ours\n")
+				_, _ = buf.WriteString(text1)
+				if text1 != "" && !strings.HasSuffix(text1, "\n") {
+					_, _ = buf.WriteString("\n")
+				}
+				_, _ = buf.WriteString("=======\n")
+				_, _ = buf.WriteString(text2)
+				if text2 != "" && !strings.HasSuffix(text2, "\n") {
+					_, _ = buf.WriteString("\n")
+				}
+				_, _ = buf.WriteString(">>>>>>> theirs\n")
+			} else {
+				_, _ = buf.WriteString(text1)
+			}
+			conflict.End = buf.Len()
+			conflicts = append(conflicts, conflict)
+		}
+		pos = c.end
+	}
+	_, _ = buf.WriteString(base[pos:])
+	return buf.String(), conflicts
+}
+
+// mergeEdits diffs text against base and returns the changes as a list of
+// edits in base coordinates.
+func (config *Config) mergeEdits(base, text string) []mergeEdit {
+	diffs := config.Diff(base, text, true)
+	if len(diffs) > 2 {
+		diffs = config.DiffCleanupSemantic(diffs)
+	}
+	var edits []mergeEdit
+	pos := 0
+	var edit *mergeEdit
+	for _, d := range diffs {
+		if d.Op == OpEqual {
+			pos += len(d.Text)
+			edit = nil
+			continue
+		}
+		if edit == nil {
+			edits = append(edits, mergeEdit{start: pos, end: pos})
+			edit = &edits[len(edits)-1]
+		}
+		if d.Op == OpDelete {
+			pos += len(d.Text)
+			edit.end = pos
+		} else {
+			edit.text += d.Text
+		}
+	}
+	return edits
+}
+
+// mergeClusters groups the edits made by both sides into clusters of
+// overlapping edits.  Conflicting clusters are widened to whole lines of
+// base.
+func mergeClusters(base string, ours, theirs []mergeEdit) []mergeCluster {
+	var clusters []mergeCluster
+	for len(ours) != 0 || len(theirs) != 0 {
+		var c mergeCluster
+		if len(theirs) == 0 || (len(ours) != 0 && ours[0].start <= theirs[0].start) {
+			c = mergeCluster{start: ours[0].start, end: ours[0].end, ours: []mergeEdit{ours[0]}}
+			ours = ours[1:]
+		} else {
+			c = mergeCluster{start: theirs[0].start, end: theirs[0].end, theirs: []mergeEdit{theirs[0]}}
+			theirs = theirs[1:]
+		}
+		// Absorb edits that overlap the cluster or start at the same place.
+		for {
+			if len(ours) != 0 && (ours[0].start < c.end || ours[0].start == c.start) {
+				c.ours = append(c.ours, ours[0])
+				c.end = max(c.end, ours[0].end)
+				ours = ours[1:]
+			} else if len(theirs) != 0 && (theirs[0].start < c.end || theirs[0].start == c.start) {
+				c.theirs = append(c.theirs, theirs[0])
+				c.end = max(c.end, theirs[0].end)
+				theirs = theirs[1:]
+			} else {
+				break
+			}
+		}
+		clusters = append(clusters, c)
+	}
+	// Widen conflicts to whole lines, merging any clusters they run into.
+	for k := 0; k < len(clusters); k++ {
+		c := &clusters[k]
+		if !c.conflicts(base) {
+			continue
+		}
+		c.start = strings.LastIndex(base[:c.start], "\n") + 1
+		if c.end != 0 && base[c.end-1] != '\n' {
+			if i := strings.Index(base[c.end:], "\n"); i != -1 {
+				c.end += i + 1
+			} else {
+				c.end = len(base)
+			}
+		}
+		if k != 0 && clusters[k-1].end > c.start {
+			// Merge into the previous cluster and widen it again.
+			clusters[k-1] = clusters[k-1].merge(*c)
+			clusters = append(clusters[:k], clusters[k+1:]...)
+			k -= 2
+			continue
+		}
+		if k+1 < len(clusters) && clusters[k+1].start < c.end {
+			// Merge the next cluster in and widen again.
+			*c = c.merge(clusters[k+1])
+			clusters = append(clusters[:k+1], clusters[k+2:]...)
+			k--
+		}
+	}
+	return clusters
+}
+
+// conflicts reports whether both sides changed the cluster's region of base
+// in different ways.
+func (c mergeCluster) conflicts(base string) bool {
+	return len(c.ours) != 0 && len(c.theirs) != 0 && c.apply(base, c.ours) != c.apply(base, c.theirs)
+}
+
+// merge returns the union of c and the following cluster d.
+func (c mergeCluster) merge(d mergeCluster) mergeCluster {
+	return mergeCluster{
+		start:  min(c.start, d.start),
+		end:    max(c.end, d.end),
+		ours:   append(append([]mergeEdit(nil), c.ours...), d.ours...),
+		theirs: append(append([]mergeEdit(nil), c.theirs...), d.theirs...),
+	}
+}
+
+// apply returns the text of the cluster's region of base with edits applied.
+func (c mergeCluster) apply(base string, edits []mergeEdit) string {
+	var buf bytes.Buffer
+	pos := c.start
+	for _, e := range edits {
+		_, _ = buf.WriteString(base[pos:e.start])
+		_, _ = buf.WriteString(e.text)
+		pos = e.end
+	}
+	_, _ = buf.WriteString(base[pos:c.end])
+	return buf.String()
+}
@@ -0,0 +1,145 @@
+package diffmatchpatch
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Base      string
+		Ours      string
+		Theirs    string
+		Expected  string
+		Conflicts []Conflict
+	}{
+		{
+			"No changes",
+			"alpha\nbeta\n",
+			"alpha\nbeta\n",
+			"alpha\nbeta\n",
+			"alpha\nbeta\n",
+			[]Conflict{},
+		},
+		{
+			"Only ours",
+			"alpha\nbeta\n",
+			"alpha\nbeta\ngamma\n",
+			"alpha\nbeta\n",
+			"alpha\nbeta\ngamma\n",
+			[]Conflict{},
+		},
+		{
+			"Only theirs",
+			"alpha\nbeta\n",
+			"alpha\nbeta\n",
+			"beta\n",
+			"beta\n",
+			[]Conflict{},
+		},
+		{
+			"Separate lines",
+			"one\ntwo\nthree\nfour\n",
+			"1\ntwo\nthree\nfour\n",
+			"one\ntwo\nthree\n4\n",
+			"1\ntwo\nthree\n4\n",
+			[]Conflict{},
+		},
+		{
+			"Separate words on one line",
+			"The quick brown fox jumps over the lazy dog.",
+			"The slow brown fox jumps over the lazy dog.",
+			"The quick brown fox jumps over the lazy cat.",
+			"The slow brown fox jumps over the lazy cat.",
+			[]Conflict{},
+		},
+		{
+			"Identical changes",
+			"one\ntwo\nthree\n",
+			"one\n2\nthree\n",
+			"one\n2\nthree\n",
+			"one\n2\nthree\n",
+			[]Conflict{},
+		},
+		{
+			"Conflict",
+			"one\ntwo\nthree\n",
+			"one\nTwo\nthree\n",
+			"one\n2\nthree\n",
+			"one\nTwo\nthree\n",
+			[]Conflict{{4, 8, "two\n", "Two\n", "2\n"}},
+		},
+		{
+			"Conflicting inserts",
+			"one\nthree\n",
+			"one\ntwo\nthree\n",
+			"one\n2\nthree\n",
+			"one\ntwo\nthree\n",
+			[]Conflict{{4, 8, "", "two\n", "2\n"}},
+		},
+		{
+			"Edit and delete",
+			"one\ntwo\nthree\n",
+			"one\ntwo!\nthree\n",
+			"one\nthree\n",
+			"one\ntwo!\nthree\n",
+			[]Conflict{{4, 9, "two\n", "two!\n", ""}},
+		},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		actual, conflicts := config.Merge3(test.Base, test.Ours, test.Theirs)
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		assert.Equal(t, test.Conflicts, conflicts, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
+
+func TestMerge3Markers(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Base     string
+		Ours     string
+		Theirs   string
+		Expected string
+	}{
+		{
+			"Clean",
+			"one\ntwo\nthree\n",
+			"1\ntwo\nthree\n",
+			"one\ntwo\n3\n",
+			"1\ntwo\n3\n",
+		},
+		{
+			"Conflict",
+			"one\ntwo\nthree\n",
+			"one\nTwo\nthree\n",
+			"one\n2\nthree\n",
+			"one\n<<<<<<< ours\nTwo\n=======\n2\n>>>>>>> theirs\nthree\n",
+		},
+		{
+			"Conflict widened to whole line",
+			"The quick brown fox.\nThe lazy dog.\n",
+			"The slow brown fox.\nThe lazy dog.\n",
+			"The fast brown fox.\nThe lazy dog.\n",
+			"<<<<<<< ours\nThe slow brown fox.\n=======\nThe fast brown fox.\n>>>>>>> theirs\nThe lazy dog.\n",
+		},
+		{
+			"No newline at end of file",
+			"one\ntwo",
+			"one\nTwo",
+			"one\n2",
+			"one\n<<<<<<< ours\nTwo\n=======\n2\n>>>>>>> theirs\n",
+		},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		actual, conflicts := config.Merge3Markers(test.Base, test.Ours, test.Theirs)
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		for _, c := range conflicts {
+			assert.Equal(t, "<<<<<<< ours\n", actual[c.Start:c.Start+13], fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		}
+	}
+}
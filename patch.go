@@ -1,12 +1,18 @@
 package diffmatchpatch
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // Patch holds information about a patch.
@@ -26,6 +32,13 @@ type Patch struct {
 //
 // Indices are printed as 1-based, not 0-based.
 func (p *Patch) String() string {
+	var buf bytes.Buffer
+	_ = writePatch(&buf, p)
+	return buf.String()
+}
+
+// writePatch writes p to w in the format of Patch.String, a line at a time.
+func writePatch(w io.Writer, p *Patch) error {
 	var coords1, coords2 string
 	if p.Length1 == 0 {
 		coords1 = strconv.Itoa(p.Start1) + ",0"
@@ -41,22 +54,23 @@ func (p *Patch) String() string {
 	} else {
 		coords2 = strconv.Itoa(p.Start2+1) + "," + strconv.Itoa(p.Length2)
 	}
-	var buf bytes.Buffer
-	_, _ = buf.WriteString("@@ -" + coords1 + " +" + coords2 + " @@\n")
+	if _, err := io.WriteString(w, "@@ -"+coords1+" +"+coords2+" @@\n"); err != nil {
+		return err
+	}
 	// Escape the body of the patch with %xx notation.
 	for _, d := range p.Diffs {
+		sign := " "
 		switch d.Op {
 		case OpInsert:
-			_, _ = buf.WriteString("+")
+			sign = "+"
 		case OpDelete:
-			_, _ = buf.WriteString("-")
-		case OpEqual:
-			_, _ = buf.WriteString(" ")
+			sign = "-"
+		}
+		if _, err := io.WriteString(w, sign+encodeURI(d.Text)+"\n"); err != nil {
+			return err
 		}
-		_, _ = buf.WriteString(strings.Replace(url.QueryEscape(d.Text), "+", " ", -1))
-		_, _ = buf.WriteString("\n")
 	}
-	return unescaper.Replace(buf.String())
+	return nil
 }
 
 // PatchAddContext increases the context until it is unique, but doesn't let
@@ -79,12 +93,12 @@ func (config *Config) PatchAddContext(patch Patch, text string) Patch {
 	// Add one chunk for good luck.
 	padding += config.PatchMargin
 	// Add the prefix.
-	prefix := text[max(0, patch.Start2-padding):patch.Start2]
+	prefix := text[runeStart(text, max(0, patch.Start2-padding)):patch.Start2]
 	if len(prefix) != 0 {
 		patch.Diffs = append([]Diff{Diff{OpEqual, prefix}}, patch.Diffs...)
 	}
 	// Add the suffix.
-	suffix := text[patch.Start2+patch.Length1 : min(len(text), patch.Start2+patch.Length1+padding)]
+	suffix := text[patch.Start2+patch.Length1 : runeEnd(text, min(len(text), patch.Start2+patch.Length1+padding))]
 	if len(suffix) != 0 {
 		patch.Diffs = append(patch.Diffs, Diff{OpEqual, suffix})
 	}
@@ -97,31 +111,61 @@ func (config *Config) PatchAddContext(patch Patch, text string) Patch {
 	return patch
 }
 
-// PatchMake computes a list of patches.
+// PatchMake computes a list of patches.  It takes either text1 and text2, a
+// []Diff, text1 and a []Diff, or text1, text2 and a third argument, where
+// text2 is ignored and the third argument is taken as a text2 or []Diff.  An
+// empty list is returned for any other arguments.
+//
+// PatchMakeFromTexts, PatchMakeFromDiffs and PatchMakeFromTextAndDiffs are
+// checked at compile time, and should be preferred.
 func (config *Config) PatchMake(opt ...interface{}) []Patch {
-	if len(opt) == 1 {
+	switch len(opt) {
+	case 1:
 		diffs, _ := opt[0].([]Diff)
-		text1 := config.DiffText1(diffs)
-		return config.PatchMake(text1, diffs)
-	} else if len(opt) == 2 {
-		text1 := opt[0].(string)
-		switch t := opt[1].(type) {
+		return config.PatchMakeFromDiffs(diffs)
+	case 2, 3:
+		text1, ok := opt[0].(string)
+		if !ok {
+			break
+		}
+		switch t := opt[len(opt)-1].(type) {
 		case string:
-			diffs := config.Diff(text1, t, true)
-			if len(diffs) > 2 {
-				diffs = config.DiffCleanupSemantic(diffs)
-				diffs = config.DiffCleanupEfficiency(diffs)
-			}
-			return config.PatchMake(text1, diffs)
+			return config.PatchMakeFromTexts(text1, t)
 		case []Diff:
 			return config.patchMake2(text1, t)
 		}
-	} else if len(opt) == 3 {
-		return config.PatchMake(opt[0], opt[2])
 	}
 	return []Patch{}
 }
 
+// PatchMakeFromTexts computes a list of patches to turn text1 into text2.
+func (config *Config) PatchMakeFromTexts(text1, text2 string) []Patch {
+	diffs := config.Diff(text1, text2, true)
+	if len(diffs) > 2 {
+		diffs = config.DiffCleanupSemantic(diffs)
+		diffs = config.DiffCleanupEfficiency(diffs)
+	}
+	return config.patchMake2(text1, diffs)
+}
+
+// PatchMakeFromDiffs computes a list of patches from diffs, with text1
+// computed from the diffs.
+func (config *Config) PatchMakeFromDiffs(diffs []Diff) []Patch {
+	return config.patchMake2(config.DiffText1(diffs), diffs)
+}
+
+// PatchMakeFromTextAndDiffs computes a list of patches to apply diffs to
+// text1.  Returns an error, giving the byte offset where they differ, if
+// diffs are not a delta from text1.
+func (config *Config) PatchMakeFromTextAndDiffs(text1 string, diffs []Diff) ([]Patch, error) {
+	if text := config.DiffText1(diffs); text != text1 {
+		runes1 := []rune(text1)
+		n := commonPrefixLength([]rune(text), runes1)
+		return nil, fmt.Errorf("diffs do not match text1 at byte %d", len(string(runes1[:n])))
+	}
+	return config.patchMake2(text1, diffs), nil
+}
+
 // patchMake2 computes a list of patches to turn text1 into text2.  text2 is
 // not provided, diffs are the delta between text1 and text2.
 func (config *Config) patchMake2(text1 string, diffs []Diff) []Patch {
@@ -211,68 +255,204 @@ func (config *Config) PatchDeepCopy(patches []Patch) []Patch {
 	return patchesCopy
 }
 
+// PatchInvert returns the inverse of a list of patches: applying it to the
+// text the patches produce gives back the text they were made from.
+func (config *Config) PatchInvert(patches []Patch) []Patch {
+	inverse := make([]Patch, len(patches))
+	for i, p := range config.PatchDeepCopy(patches) {
+		var diffs []Diff
+		for j := 0; j < len(p.Diffs); {
+			if p.Diffs[j].Op == OpEqual {
+				diffs = append(diffs, p.Diffs[j])
+				j++
+				continue
+			}
+			// Swap the insertions and deletions of each change, keeping
+			// deletions first.
+			var deleted, inserted string
+			for ; j < len(p.Diffs) && p.Diffs[j].Op != OpEqual; j++ {
+				if p.Diffs[j].Op == OpInsert {
+					deleted += p.Diffs[j].Text
+				} else {
+					inserted += p.Diffs[j].Text
+				}
+			}
+			diffs = diffAppend(diffs, Diff{OpDelete, deleted}, Diff{OpInsert, inserted})
+		}
+		p.Diffs = diffs
+		p.Start1, p.Start2 = p.Start2, p.Start1
+		p.Length1, p.Length2 = p.Length2, p.Length1
+		// Patches apply one after another, so undo them in reverse.
+		inverse[len(patches)-1-i] = p
+	}
+	return inverse
+}
+
 // PatchApply merges a set of patches onto the text.  Returns a patched text,
 // as well as an array of true/false values indicating which patches were
 // applied.
 func (config *Config) PatchApply(patches []Patch, text string) (string, []bool) {
+	text, results, _ := config.PatchApplyContext(context.Background(), patches, text)
+	return text, results
+}
+
+// PatchApplyContext merges a set of patches onto the text, stopping early and
+// returning ctx.Err() when ctx is done.  Returns a patched text, as well as an
+// array of true/false values indicating which patches were applied.
+func (config *Config) PatchApplyContext(ctx context.Context, patches []Patch, text string) (string, []bool, error) {
+	text, results, _, err := config.patchApply(ctx, patches, text)
+	return text, results, err
+}
+
+// PatchReport describes how a patch was applied.
+type PatchReport struct {
+	// Applied is whether the patch was applied.  Patches longer than
+	// PatchMaxLength are applied in parts, and are only applied if every part
+	// was.  The parts are applied independently, so the parts that were
+	// applied have changed the text even when others were not.
+	Applied bool
+	// Parts is how each part of the patch was applied.
+	Parts []PatchPart
+	// ExpectedLoc is where the first part of the patch was expected in the
+	// text, allowing for how far earlier patches were from where they were
+	// expected.  Loc is where it was found, or -1.
+	ExpectedLoc int
+	Loc         int
+	// Exact is whether the text the patch changes was found unaltered.
+	Exact bool
+	// Score is the Levenshtein distance between the text the patch changes
+	// and the text found in its place, over the length of the text, for the
+	// worst matching part.  0 is an exact match.  Parts longer than
+	// PatchMaxLength are not applied when their score is over
+	// PatchDeleteThreshold.
+	Score float64
+	// Start and End are the range of the output text changed by the parts of
+	// the patch that were applied, including their context.  Both are -1 if
+	// no part was applied.
+	Start int
+	End   int
+}
+
+// PatchPart describes how a part of a patch was applied.
+type PatchPart struct {
+	// Applied is whether the part was applied.
+	Applied bool
+	// ExpectedLoc is where the part was expected in the text, and Loc is
+	// where it was found, or -1.  A part found but not applied matched too
+	// poorly.
+	ExpectedLoc int
+	Loc         int
+	// Score is the score of the part, as for PatchReport.
+	Score float64
+	// Start and End are the range of the output text changed by the part,
+	// including its context.  Both are -1 if the part was not applied.
+	Start int
+	End   int
+}
+
+// PatchApplyReport merges a set of patches onto the text.  Returns a patched
+// text, and a report of how each patch was applied.  Unlike PatchApply, the
+// reports correspond to the given patches, not the parts they are split into.
+func (config *Config) PatchApplyReport(patches []Patch, text string) (string, []PatchReport) {
+	text, _, reports, _ := config.patchApply(context.Background(), patches, text)
+	return text, reports
+}
+
+// PatchApplyReportContext is like PatchApplyReport, stopping early and
+// returning ctx.Err() when ctx is done.
+func (config *Config) PatchApplyReportContext(ctx context.Context, patches []Patch, text string) (string, []PatchReport, error) {
+	text, _, reports, err := config.patchApply(ctx, patches, text)
+	return text, reports, err
+}
+
+// patchApply merges a set of patches onto the text.  Returns a patched text,
+// whether each part of the patches (as split by PatchSplitMax) was applied,
+// and a report for each patch.
+func (config *Config) patchApply(ctx context.Context, patches []Patch, text string) (string, []bool, []PatchReport, error) {
 	if len(patches) == 0 {
-		return text, []bool{}
+		return text, []bool{}, []PatchReport{}, nil
 	}
 	// Deep copy the patches so that no changes are made to originals.
 	patches = config.PatchDeepCopy(patches)
 	nullPadding := config.PatchAddPadding(patches)
 	text = nullPadding + text + nullPadding
-	patches = config.PatchSplitMax(patches)
-	x := 0
+	// Split the patches one at a time, to know which patch each part is from.
+	reports := make([]PatchReport, len(patches))
+	var parts []Patch
+	var origins []int
+	for i, p := range patches {
+		split := config.PatchSplitMax([]Patch{p})
+		parts = append(parts, split...)
+		for range split {
+			origins = append(origins, i)
+		}
+		reports[i] = PatchReport{Applied: true, Parts: []PatchPart{}, ExpectedLoc: -1, Loc: -1, Exact: true, Start: -1, End: -1}
+	}
+	// replace replaces text[start:end] with s, moving the ranges of the
+	// reports and their parts to match.
+	replace := func(start, end int, s string) {
+		text = text[:start] + s + text[end:]
+		d := len(s) - (end - start)
+		move := func(rStart, rEnd *int) {
+			switch {
+			case *rStart == -1 || start >= *rEnd:
+			case end <= *rStart:
+				*rStart += d
+				*rEnd += d
+			default:
+				*rStart = min(*rStart, start)
+				*rEnd = max(*rEnd+d, start+len(s))
+			}
+		}
+		for i := range reports {
+			r := &reports[i]
+			move(&r.Start, &r.End)
+			for j := range r.Parts {
+				move(&r.Parts[j].Start, &r.Parts[j].End)
+			}
+		}
+	}
 	// delta keeps track of the offset between the expected and actual location
 	// of the previous patch.  If there are patches expected at positions 10
 	// and 20, but the first patch was found at 12, delta is 2 and the second
 	// patch has an effective expected position of 22.
 	delta := 0
-	results := make([]bool, len(patches))
-	for _, p := range patches {
+	results := make([]bool, len(parts))
+	for x, p := range parts {
+		report := &reports[origins[x]]
 		expectedLoc := p.Start2 + delta
 		text1 := config.DiffText1(p.Diffs)
-		var startLoc int
-		endLoc := -1
-		if len(text1) > config.MatchMaxBits {
-			// PatchSplitMax will only provide an oversized pattern in the case
-			// of a monster delete.
-			startLoc = config.Match(text, text1[:config.MatchMaxBits], expectedLoc)
-			if startLoc != -1 {
-				endLoc = config.Match(text,
-					text1[len(text1)-config.MatchMaxBits:], expectedLoc+len(text1)-config.MatchMaxBits)
-				if endLoc == -1 || startLoc >= endLoc {
-					// Can't find valid trailing context.  Drop this patch.
-					startLoc = -1
-				}
-			}
-		} else {
-			startLoc = config.Match(text, text1, expectedLoc)
+		startLoc, endLoc := config.patchMatch(ctx, text, text1, expectedLoc)
+		if err := ctx.Err(); err != nil {
+			return "", nil, nil, err
 		}
+		part := PatchPart{ExpectedLoc: expectedLoc, Loc: startLoc, Start: -1, End: -1}
+		// The range of this part in the text, once applied.
+		partStart, partEnd := startLoc, endLoc
 		if startLoc == -1 {
 			// No match found.  :(
 			results[x] = false
-			// Subtract the delta for this failed patch from subsequent patches.
-			delta -= p.Length2 - p.Length1
 		} else {
 			// Found a match.  :)
 			results[x] = true
-			delta = startLoc - expectedLoc
-			var text2 string
-			if endLoc == -1 {
-				text2 = text[startLoc:min(startLoc+len(text1), len(text))]
-			} else {
-				text2 = text[startLoc:min(endLoc+config.MatchMaxBits, len(text))]
-			}
+			text2 := text[startLoc:endLoc]
 			if text1 == text2 {
 				// Perfect match, just shove the Replacement text in.
-				text = text[:startLoc] + config.DiffText2(p.Diffs) + text[startLoc+len(text1):]
+				replacement := config.DiffText2(p.Diffs)
+				replace(startLoc, startLoc+len(text1), replacement)
+				partEnd = startLoc + len(replacement)
 			} else {
 				// Imperfect match.  Run a diff to get a framework of
 				// equivalent indices.
-				diffs := config.Diff(text1, text2, false)
-				if len(text1) > config.MatchMaxBits && float64(config.DiffLevenshtein(diffs))/float64(len(text1)) > config.PatchDeleteThreshold {
+				diffs, err := config.DiffContext(ctx, text1, text2, false)
+				if err != nil {
+					return "", nil, nil, err
+				}
+				report.Exact = false
+				score := float64(config.DiffLevenshtein(diffs)) / float64(len(text1))
+				report.Score = math.Max(report.Score, score)
+				part.Score = score
+				if config.PatchMaxLength > 0 && len(text1) > config.PatchMaxLength && score > config.PatchDeleteThreshold {
 					// The end points match, but the content is unacceptably bad.
 					results[x] = false
 				} else {
@@ -283,12 +463,14 @@ func (config *Config) PatchApply(patches []Patch, text string) (string, []bool)
 							index2 := config.DiffXIndex(diffs, index1)
 							if d.Op == OpInsert {
 								// Insertion
-								text = text[:startLoc+index2] + d.Text + text[startLoc+index2:]
+								replace(startLoc+index2, startLoc+index2, d.Text)
+								partEnd += len(d.Text)
 							} else if d.Op == OpDelete {
 								// Deletion
 								startIndex := startLoc + index2
-								text = text[:startIndex] +
-									text[startIndex+config.DiffXIndex(diffs, index1+len(d.Text))-index2:]
+								endIndex := startIndex + config.DiffXIndex(diffs, index1+len(d.Text)) - index2
+								replace(startIndex, endIndex, "")
+								partEnd -= endIndex - startIndex
 							}
 						}
 						if d.Op != OpDelete {
@@ -298,10 +480,221 @@ func (config *Config) PatchApply(patches []Patch, text string) (string, []bool)
 				}
 			}
 		}
-		x++
+		// Only an applied patch tells where the next is expected; a patch
+		// found but rejected leaves delta as it was.
+		if results[x] {
+			delta = startLoc - expectedLoc
+		} else if startLoc == -1 {
+			// Subtract the delta for this failed patch from subsequent patches.
+			delta -= p.Length2 - p.Length1
+		}
+		if !results[x] {
+			report.Parts = append(report.Parts, part)
+			report.Applied, report.Exact = false, false
+			continue
+		}
+		part.Applied, part.Start, part.End = true, partStart, partEnd
+		report.Parts = append(report.Parts, part)
+		if report.Start == -1 {
+			report.Start, report.End = partStart, partEnd
+		} else {
+			report.Start, report.End = min(report.Start, partStart), max(report.End, partEnd)
+		}
 	}
 	// strip padding
-	return text[len(nullPadding) : len(nullPadding)+(len(text)-2*len(nullPadding))], results
+	text = text[len(nullPadding) : len(text)-len(nullPadding)]
+	// Locations within the padding are at the start of the text.
+	unpad := func(loc int) int {
+		if loc == -1 {
+			return -1
+		}
+		return min(max(loc-len(nullPadding), 0), len(text))
+	}
+	for i := range reports {
+		r := &reports[i]
+		r.Start, r.End = unpad(r.Start), unpad(r.End)
+		for j := range r.Parts {
+			part := &r.Parts[j]
+			part.ExpectedLoc, part.Loc = max(part.ExpectedLoc-len(nullPadding), 0), unpad(part.Loc)
+			part.Start, part.End = unpad(part.Start), unpad(part.End)
+		}
+		if len(r.Parts) != 0 {
+			r.ExpectedLoc, r.Loc = r.Parts[0].ExpectedLoc, r.Parts[0].Loc
+		}
+	}
+	return text, results, reports, nil
+}
+
+// PatchFailure describes a patch that could not be applied.
+type PatchFailure struct {
+	// Index is the index of the patch.
+	Index int
+	// Patch is the patch.
+	Patch Patch
+	// Report is how the patch was applied.
+	Report PatchReport
+	// Reason is why the patch could not be applied.
+	Reason string
+}
+
+// PatchApplyError is the error returned by PatchApplyAll when any patches
+// could not be applied.
+type PatchApplyError struct {
+	// Failures are the patches that could not be applied, in order.
+	Failures []PatchFailure
+	// Total is the number of patches.
+	Total int
+}
+
+// Error satisfies the error interface.
+func (err *PatchApplyError) Error() string {
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "%d of %d patches failed", len(err.Failures), err.Total)
+	for i, f := range err.Failures {
+		sep := "; "
+		if i == 0 {
+			sep = ": "
+		}
+		header := f.Patch.String()
+		_, _ = fmt.Fprintf(&buf, "%spatch %d (%s) %s", sep, f.Index, header[:strings.IndexByte(header, '\n')], f.Reason)
+	}
+	return buf.String()
+}
+
+// PatchApplyAll merges a set of patches onto the text, like PatchApply, but
+// only if every patch can be applied.  Otherwise the text is returned
+// unchanged with a *PatchApplyError describing the patches that failed.
+func (config *Config) PatchApplyAll(patches []Patch, text string) (string, error) {
+	return config.PatchApplyAllContext(context.Background(), patches, text)
+}
+
+// PatchApplyAllContext is like PatchApplyAll, stopping early and returning
+// ctx.Err() when ctx is done.
+func (config *Config) PatchApplyAllContext(ctx context.Context, patches []Patch, text string) (string, error) {
+	patched, reports, err := config.PatchApplyReportContext(ctx, patches, text)
+	if err != nil {
+		return text, err
+	}
+	var failures []PatchFailure
+	for i, r := range reports {
+		if r.Applied {
+			continue
+		}
+		failures = append(failures, PatchFailure{
+			Index:  i,
+			Patch:  patches[i],
+			Report: r,
+			Reason: config.patchFailureReason(r),
+		})
+	}
+	if len(failures) != 0 {
+		return text, &PatchApplyError{Failures: failures, Total: len(patches)}
+	}
+	return patched, nil
+}
+
+// patchFailureReason describes why a patch with the report r was not
+// applied, from the first part of it that was not applied.
+func (config *Config) patchFailureReason(r PatchReport) string {
+	failed, first := 0, -1
+	for i, part := range r.Parts {
+		if !part.Applied {
+			if first == -1 {
+				first = i
+			}
+			failed++
+		}
+	}
+	if first == -1 {
+		return fmt.Sprintf("could not match near %d", r.ExpectedLoc)
+	}
+	part := r.Parts[first]
+	switch {
+	case part.Loc != -1:
+		// The part was found, but matched too poorly to be applied.
+		return fmt.Sprintf("matched with score %.2f, over the delete threshold of %.2f", part.Score, config.PatchDeleteThreshold)
+	case len(r.Parts) > 1:
+		return fmt.Sprintf("could not match %d of %d parts near %d", failed, len(r.Parts), part.ExpectedLoc)
+	}
+	return fmt.Sprintf("could not match near %d", part.ExpectedLoc)
+}
+
+// PatchMismatchError is the error returned by PatchApplyStrict when a patch
+// does not match the text.
+type PatchMismatchError struct {
+	// Index is the index of the patch.
+	Index int
+	// Patch is the patch.
+	Patch Patch
+	// Loc is where the patch was expected in the text, with the patches
+	// before it applied.
+	Loc int
+	// Expected is the text the patch expected at Loc, and Found is the text
+	// there.
+	Expected string
+	Found    string
+}
+
+// Error satisfies the error interface.
+func (err *PatchMismatchError) Error() string {
+	header := err.Patch.String()
+	header = header[:strings.IndexByte(header, '\n')]
+	return fmt.Sprintf("patch %d (%s) does not match text at %d: expected %q, found %q", err.Index, header, err.Loc, err.Expected, err.Found)
+}
+
+// PatchApplyStrict merges a set of patches onto the text, applying each patch
+// only where it is expected and only if the text there matches it exactly,
+// like git apply without fuzz.  Either every patch is applied, or the text is
+// returned unchanged with a *PatchMismatchError for the first patch that does
+// not match.
+func (config *Config) PatchApplyStrict(patches []Patch, text string) (string, error) {
+	patched := text
+	for i, p := range patches {
+		text1 := config.DiffText1(p.Diffs)
+		loc := p.Start2
+		if loc < 0 || loc+len(text1) > len(patched) || patched[loc:loc+len(text1)] != text1 {
+			found := ""
+			if loc >= 0 && loc < len(patched) {
+				found = patched[loc:runeEnd(patched, min(loc+len(text1), len(patched)))]
+			}
+			return text, &PatchMismatchError{
+				Index:    i,
+				Patch:    p,
+				Loc:      loc,
+				Expected: text1,
+				Found:    found,
+			}
+		}
+		patched = patched[:loc] + config.DiffText2(p.Diffs) + patched[loc+len(text1):]
+	}
+	return patched, nil
+}
+
+// patchMatch locates the best instance of pattern in text near loc, returning
+// the start and end of the match.  Returns -1, -1 if no match was found.
+func (config *Config) patchMatch(ctx context.Context, text, pattern string, loc int) (int, int) {
+	if config.PatchMaxLength <= 0 || len(pattern) <= config.PatchMaxLength {
+		start := config.match(ctx, text, pattern, loc)
+		if start == -1 {
+			return -1, -1
+		}
+		return start, runeEnd(text, min(start+len(pattern), len(text)))
+	}
+	// Patterns PatchSplitMax could not split (e.g. a big delete) are located
+	// by their first and last PatchMaxLength bytes, and held to
+	// PatchDeleteThreshold once found.
+	head := pattern[:runeEnd(pattern, config.PatchMaxLength)]
+	tail := pattern[runeStart(pattern, len(pattern)-config.PatchMaxLength):]
+	start := config.match(ctx, text, head, loc)
+	if start == -1 {
+		return -1, -1
+	}
+	end := config.match(ctx, text, tail, loc+len(pattern)-len(tail))
+	if end == -1 || start >= end {
+		// Can't find valid trailing context.
+		return -1, -1
+	}
+	return start, runeEnd(text, min(end+len(tail), len(text)))
 }
 
 // PatchAddPadding adds some padding on text start and end so that edges can
@@ -353,10 +746,13 @@ func (config *Config) PatchAddPadding(patches []Patch) string {
 }
 
 // PatchSplitMax looks through the patches and breaks up any which are longer
-// than the maximum limit of the match algorithm.  Intended to be called only
-// from within patchApply.
+// than PatchMaxLength.  Patches are left whole when PatchMaxLength is 0.
+// Intended to be called only from within patchApply.
 func (config *Config) PatchSplitMax(patches []Patch) []Patch {
-	patchSize := config.MatchMaxBits
+	patchSize := config.PatchMaxLength
+	if patchSize <= 0 {
+		return patches
+	}
 	for x := 0; x < len(patches); x++ {
 		if patches[x].Length1 <= patchSize {
 			continue
@@ -398,7 +794,12 @@ func (config *Config) PatchSplitMax(patches []Patch) []Patch {
 					bigpatch.Diffs = bigpatch.Diffs[1:]
 				} else {
 					// Deletion or equality.  Only take as much as we can stomach.
-					diffText = diffText[:min(len(diffText), patchSize-patch.Length1-config.PatchMargin)]
+					n := runeStart(diffText, min(len(diffText), patchSize-patch.Length1-config.PatchMargin))
+					if n == 0 {
+						// Always take at least one whole rune.
+						_, n = utf8.DecodeRuneInString(diffText)
+					}
+					diffText = diffText[:n]
 					patch.Length1 += len(diffText)
 					Start1 += len(diffText)
 					if diffType == OpEqual {
@@ -418,13 +819,13 @@ func (config *Config) PatchSplitMax(patches []Patch) []Patch {
 			}
 			// Compute the head context for the next patch.
 			precontext = config.DiffText2(patch.Diffs)
-			precontext = precontext[max(0, len(precontext)-config.PatchMargin):]
+			precontext = precontext[runeStart(precontext, max(0, len(precontext)-config.PatchMargin)):]
 			postcontext := ""
 			// Append the end context for this patch.
-			if len(config.DiffText1(bigpatch.Diffs)) > config.PatchMargin {
-				postcontext = config.DiffText1(bigpatch.Diffs)[:config.PatchMargin]
+			if text1 := config.DiffText1(bigpatch.Diffs); len(text1) > config.PatchMargin {
+				postcontext = text1[:runeEnd(text1, config.PatchMargin)]
 			} else {
-				postcontext = config.DiffText1(bigpatch.Diffs)
+				postcontext = text1
 			}
 			if len(postcontext) != 0 {
 				patch.Length1 += len(postcontext)
@@ -447,31 +848,76 @@ func (config *Config) PatchSplitMax(patches []Patch) []Patch {
 // PatchToText takes a list of patches and returns a textual representation.
 func (config *Config) PatchToText(patches []Patch) string {
 	var buf bytes.Buffer
-	for _, p := range patches {
-		_, _ = buf.WriteString(p.String())
-	}
+	_ = config.WritePatches(&buf, patches)
 	return buf.String()
 }
 
+// WritePatches writes the textual representation of a list of patches to w,
+// as returned by PatchToText, a line at a time.
+func (config *Config) WritePatches(w io.Writer, patches []Patch) error {
+	for i := range patches {
+		if err := writePatch(w, &patches[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // PatchFromText parses a textual representation of patches and returns a List
 // of Patch objects.
 func (config *Config) PatchFromText(textline string) ([]Patch, error) {
+	return config.ReadPatches(strings.NewReader(textline))
+}
+
+// ReadPatches reads and parses a textual representation of patches from r, a
+// line at a time, as PatchFromText.
+func (config *Config) ReadPatches(r io.Reader) ([]Patch, error) {
 	patches := []Patch{}
-	if len(textline) == 0 {
-		return patches, nil
-	}
-	text := strings.Split(textline, "\n")
-	textPointer := 0
+	br := bufio.NewReader(r)
 	patchHeader := regexp.MustCompile(`^@@ -(\d+),?(\d*) \+(\d+),?(\d*) @@$`)
-	var patch Patch
-	var sign uint8
-	var line string
-	for textPointer < len(text) {
-		if !patchHeader.MatchString(text[textPointer]) {
-			return patches, errors.New("Invalid patch string: " + text[textPointer])
-		}
-		patch = Patch{}
-		m := patchHeader.FindStringSubmatch(text[textPointer])
+	var patch *Patch
+	for n, done := 0, false; !done; n++ {
+		text, err := br.ReadString('\n')
+		switch {
+		case err == io.EOF && n == 0 && len(text) == 0:
+			return patches, nil
+		case err == io.EOF:
+			done = true
+		case err != nil:
+			return patches, err
+		}
+		text = strings.TrimSuffix(text, "\n")
+		if patch != nil {
+			if len(text) == 0 {
+				continue
+			}
+			sign, line := text[0], text[1:]
+			line = strings.Replace(line, "+", "%2b", -1)
+			line, _ = url.QueryUnescape(line)
+			if sign == '-' {
+				// Deletion.
+				patch.Diffs = append(patch.Diffs, Diff{OpDelete, line})
+				continue
+			} else if sign == '+' {
+				// Insertion.
+				patch.Diffs = append(patch.Diffs, Diff{OpInsert, line})
+				continue
+			} else if sign == ' ' {
+				// Minor equality.
+				patch.Diffs = append(patch.Diffs, Diff{OpEqual, line})
+				continue
+			} else if sign != '@' {
+				// WTF?
+				return patches, errors.New("Invalid patch mode '" + string(sign) + "' in: " + string(line))
+			}
+			// Start of next patch.
+			patches, patch = append(patches, *patch), nil
+		}
+		if !patchHeader.MatchString(text) {
+			return patches, errors.New("Invalid patch string: " + text)
+		}
+		patch = &Patch{}
+		m := patchHeader.FindStringSubmatch(text)
 		patch.Start1, _ = strconv.Atoi(m[1])
 		if len(m[2]) == 0 {
 			patch.Start1--
@@ -492,36 +938,9 @@ func (config *Config) PatchFromText(textline string) ([]Patch, error) {
 			patch.Start2--
 			patch.Length2, _ = strconv.Atoi(m[4])
 		}
-		textPointer++
-		for textPointer < len(text) {
-			if len(text[textPointer]) > 0 {
-				sign = text[textPointer][0]
-			} else {
-				textPointer++
-				continue
-			}
-			line = text[textPointer][1:]
-			line = strings.Replace(line, "+", "%2b", -1)
-			line, _ = url.QueryUnescape(line)
-			if sign == '-' {
-				// Deletion.
-				patch.Diffs = append(patch.Diffs, Diff{OpDelete, line})
-			} else if sign == '+' {
-				// Insertion.
-				patch.Diffs = append(patch.Diffs, Diff{OpInsert, line})
-			} else if sign == ' ' {
-				// Minor equality.
-				patch.Diffs = append(patch.Diffs, Diff{OpEqual, line})
-			} else if sign == '@' {
-				// Start of next patch.
-				break
-			} else {
-				// WTF?
-				return patches, errors.New("Invalid patch mode '" + string(sign) + "' in: " + string(line))
-			}
-			textPointer++
-		}
-		patches = append(patches, patch)
+	}
+	if patch != nil {
+		patches = append(patches, *patch)
 	}
 	return patches, nil
 }
@@ -1,9 +1,14 @@
 package diffmatchpatch
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -95,6 +100,55 @@ func TestPatchToText(t *testing.T) {
 	}
 }
 
+func TestWritePatches(t *testing.T) {
+	text := "@@ -1,9 +1,9 @@\n-f\n+F\n oo+fooba\n@@ -7,9 +7,9 @@\n obar\n-,\n+.\n  tes\n"
+	config := NewDefaultConfig()
+	patches, err := config.PatchFromText(text)
+	assert.Nil(t, err)
+	var buf bytes.Buffer
+	assert.Nil(t, config.WritePatches(&buf, patches))
+	assert.Equal(t, text, buf.String())
+	// Write errors are returned.
+	for n := 0; n < len(text); n++ {
+		assert.EqualError(t, config.WritePatches(&errWriter{n}, patches), "write failed", fmt.Sprintf("n = %d", n))
+	}
+}
+
+func TestReadPatches(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Text     string
+		Expected int
+		Error    string
+	}{
+		{"Empty", "", 0, ""},
+		{"Patches", "@@ -1,9 +1,9 @@\n-f\n+F\n oo+fooba\n@@ -7,9 +7,9 @@\n obar\n-,\n+.\n  tes\n", 2, ""},
+		{"No final newline", "@@ -1 +1 @@\n-a\n+b", 1, ""},
+		{"Blank lines", "@@ -1 +1 @@\n\n-a\n\n+b\n\n", 1, ""},
+		{"Bad header", "@@ -1 +1 @@\n-a\n+b\n@@ junk\n", 1, "Invalid patch string: @@ junk"},
+		{"Bad mode", "@@ -1 +1 @@\n*a\n", 0, "Invalid patch mode '*' in: a"},
+		{"Leading blank line", "\n@@ -1 +1 @@\n", 0, "Invalid patch string: "},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		expected, expectedErr := config.PatchFromText(test.Text)
+		actual, err := config.ReadPatches(iotest.OneByteReader(strings.NewReader(test.Text)))
+		assert.Equal(t, expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		assert.Len(t, actual, test.Expected, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		if test.Error == "" {
+			assert.Nil(t, err, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+			assert.Nil(t, expectedErr, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		} else {
+			assert.EqualError(t, err, test.Error, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+			assert.EqualError(t, expectedErr, test.Error, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		}
+	}
+	// Read errors are returned.
+	r := io.MultiReader(strings.NewReader("@@ -1 +1 @@\n-a\n"), iotest.ErrReader(errors.New("read failed")))
+	_, err := config.ReadPatches(r)
+	assert.EqualError(t, err, "read failed")
+}
+
 func TestPatchAddContext(t *testing.T) {
 	tests := []struct {
 		Name     string
@@ -190,6 +244,13 @@ func TestPatchMakeAndPatchToText(t *testing.T) {
 			config.Diff(text1, text2, false),
 			"@@ -1,11 +1,12 @@\n Th\n-e\n+at\n  quick b\n@@ -22,18 +22,17 @@\n jump\n-s\n+ed\n  over \n-the\n+a\n  laz\n",
 		},
+		{
+			"Text1+Text2+Text2 inputs (deprecated)",
+			text1,
+			"ignored",
+			text2,
+			"@@ -1,11 +1,12 @@\n Th\n-e\n+at\n  quick b\n@@ -22,18 +22,17 @@\n jump\n-s\n+ed\n  over \n-the\n+a\n  laz\n",
+		},
 		{
 			"Character encoding",
 			"`1234567890-=[]\\;',./",
@@ -240,6 +301,48 @@ func TestPatchMakeAndPatchToText(t *testing.T) {
 	// Check that empty Patch array is returned for no parameter call
 	patches = config.PatchMake()
 	assert.Equal(t, []Patch{}, patches)
+	// Unsupported arguments return an empty Patch array rather than panic.
+	assert.Equal(t, []Patch{}, config.PatchMake(1, "two"))
+	assert.Equal(t, []Patch{}, config.PatchMake("one", 2, 3))
+}
+
+func TestPatchMakeFrom(t *testing.T) {
+	config := NewDefaultConfig()
+	text1 := "The quick brown fox jumps over the lazy dog."
+	text2 := "That quick brown fox jumped over a lazy dog."
+	expected := "@@ -1,11 +1,12 @@\n Th\n-e\n+at\n  quick b\n@@ -22,18 +22,17 @@\n jump\n-s\n+ed\n  over \n-the\n+a\n  laz\n"
+	assert.Equal(t, expected, config.PatchToText(config.PatchMakeFromTexts(text1, text2)))
+	assert.Equal(t, []Patch{}, config.PatchMakeFromTexts(text1, text1))
+
+	diffs := config.Diff(text1, text2, false)
+	assert.Equal(t, expected, config.PatchToText(config.PatchMakeFromDiffs(diffs)))
+	assert.Equal(t, []Patch{}, config.PatchMakeFromDiffs(nil))
+
+	tests := []struct {
+		Name     string
+		Text1    string
+		Diffs    []Diff
+		Expected string
+		Err      string
+	}{
+		{"Matching text", text1, diffs, expected, ""},
+		{"No diffs", "", nil, "", ""},
+		{"Different text", "The quick brown cat jumps over the lazy dog.", diffs, "", "diffs do not match text1 at byte 16"},
+		{"Different multibyte text", "日本語のテキスト", []Diff{{OpEqual, "日本人のテキスト"}}, "", "diffs do not match text1 at byte 6"},
+		{"Text missing", "", diffs, "", "diffs do not match text1 at byte 0"},
+		{"Diffs missing", text1, nil, "", "diffs do not match text1 at byte 0"},
+	}
+	for i, test := range tests {
+		patches, err := config.PatchMakeFromTextAndDiffs(test.Text1, test.Diffs)
+		msg := fmt.Sprintf("Test case #%d, %s", i, test.Name)
+		if test.Err != "" {
+			assert.EqualError(t, err, test.Err, msg)
+			assert.Nil(t, patches, msg)
+			continue
+		}
+		assert.Nil(t, err, msg)
+		assert.Equal(t, test.Expected, config.PatchToText(patches), msg)
+	}
 }
 
 func TestPatchSplitMax(t *testing.T) {
@@ -276,6 +379,13 @@ func TestPatchSplitMax(t *testing.T) {
 		actual := config.PatchToText(patches)
 		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %#v", i, test))
 	}
+	// Patches are left whole without a maximum length.
+	config.PatchMaxLength = 0
+	for i, test := range tests {
+		patches := config.PatchMake(test.Text1, test.Text2)
+		actual := config.PatchSplitMax(config.PatchDeepCopy(patches))
+		assert.Equal(t, patches, actual, fmt.Sprintf("Test case #%d, %#v", i, test))
+	}
 }
 
 func TestPatchAddPadding(t *testing.T) {
@@ -430,6 +540,15 @@ func TestPatchApply(t *testing.T) {
 			"x123",
 			[]bool{true},
 		},
+		{
+			"Unicode fuzzy match",
+			"Съешь же ещё этих мягких французских булок, да выпей чаю.",
+			"Съешь же ещё этих свежих французских булок, да выпей кофе.",
+			"Съешь ещё этих мягких французских булочек, да выпей чаю.",
+			1000, 0.5, 0.5,
+			"Съешь ещё этих свежих французских булочек, да выпей кофе.",
+			[]bool{true, true},
+		},
 	}
 	for i, test := range tests {
 		config := NewDefaultConfig()
@@ -442,3 +561,391 @@ func TestPatchApply(t *testing.T) {
 		assert.Equal(t, test.ExpectedApplies, actualApplies, fmt.Sprintf("Test case #%d, %s", i, test.Name))
 	}
 }
+
+func TestPatchApplyLongContext(t *testing.T) {
+	text1 := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 20)
+	text2 := strings.Replace(text1, "lazy dog. The quick", "sleepy cat. The slow", 1)
+	textBase := strings.Replace(text1, "fox", "wolf", -1)
+	config := NewDefaultConfig()
+	config.MatchMaxBits = 1024
+	config.PatchMaxLength = 0
+	patches := config.PatchMake(text1, text2)
+	assert.Len(t, config.PatchSplitMax(config.PatchDeepCopy(patches)), 1)
+	actual, actualApplies := config.PatchApply(patches, textBase)
+	assert.Equal(t, strings.Replace(textBase, "lazy dog. The quick", "sleepy cat. The slow", 1), actual)
+	assert.Equal(t, []bool{true}, actualApplies)
+}
+
+func TestPatchApplyContext(t *testing.T) {
+	config := NewDefaultConfig()
+	patches := config.PatchMake("The quick brown fox jumps over the lazy dog.", "That quick brown fox jumped over a lazy dog.")
+	text, applies, err := config.PatchApplyContext(context.Background(), patches, "The quick red rabbit jumps over the tired tiger.")
+	assert.Nil(t, err)
+	assert.Equal(t, "That quick red rabbit jumped over a tired tiger.", text)
+	assert.Equal(t, []bool{true, true}, applies)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	text, applies, err = config.PatchApplyContext(ctx, patches, "The quick red rabbit jumps over the tired tiger.")
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, "", text)
+	assert.Nil(t, applies)
+}
+
+func TestPatchApplyReport(t *testing.T) {
+	fox1 := "The quick brown fox jumps over the lazy dog."
+	fox2 := "That quick brown fox jumped over a lazy dog."
+	x1 := "x1234567890123456789012345678901234567890123456789012345678901234567890y"
+	tests := []struct {
+		Name     string
+		Text1    string
+		Text2    string
+		TextBase string
+		Expected string
+		Reports  []PatchReport
+		Changed  []string
+	}{
+		{
+			"Null case",
+			"", "", "Hello world.",
+			"Hello world.",
+			[]PatchReport{},
+			nil,
+		},
+		{
+			"Exact match",
+			fox1, fox2, fox1,
+			fox2,
+			[]PatchReport{
+				{Applied: true, Parts: []PatchPart{{Applied: true, ExpectedLoc: 0, Loc: 0, Start: 0, End: 12}}, ExpectedLoc: 0, Loc: 0, Exact: true, Start: 0, End: 12},
+				{Applied: true, Parts: []PatchPart{{Applied: true, ExpectedLoc: 21, Loc: 21, Start: 21, End: 38}}, ExpectedLoc: 21, Loc: 21, Exact: true, Start: 21, End: 38},
+			},
+			[]string{"That quick b", "jumped over a laz"},
+		},
+		{
+			"Fuzzy match",
+			fox1, fox2, "Wow! The quick red rabbit jumps over the tired tiger.",
+			"Wow! That quick red rabbit jumped over a tired tiger.",
+			[]PatchReport{
+				{Applied: true, Parts: []PatchPart{{Applied: true, ExpectedLoc: 0, Loc: 3, Score: 3.0 / 13, Start: 3, End: 17}}, ExpectedLoc: 0, Loc: 3, Score: 3.0 / 13, Start: 3, End: 17},
+				{Applied: true, Parts: []PatchPart{{Applied: true, ExpectedLoc: 26, Loc: 27, Score: 3.0 / 18, Start: 27, End: 44}}, ExpectedLoc: 26, Loc: 27, Score: 3.0 / 18, Start: 27, End: 44},
+			},
+			[]string{"! That quick r", "jumped over a tir"},
+		},
+		{
+			"Failed match",
+			fox1, fox2, "I am the very model of a modern major general.",
+			"I am the very model of a modern major general.",
+			[]PatchReport{
+				{Parts: []PatchPart{{ExpectedLoc: 0, Loc: -1, Start: -1, End: -1}}, ExpectedLoc: 0, Loc: -1, Start: -1, End: -1},
+				{Parts: []PatchPart{{ExpectedLoc: 20, Loc: -1, Start: -1, End: -1}}, ExpectedLoc: 20, Loc: -1, Start: -1, End: -1},
+			},
+			nil,
+		},
+		{
+			"Big delete applied in parts",
+			x1, "xabcy", "x123456789012345678901234567890-----++++++++++-----123456789012345678901234567890y",
+			"xabcy",
+			[]PatchReport{
+				{
+					Applied: true,
+					Parts: []PatchPart{
+						{Applied: true, ExpectedLoc: 0, Loc: 0, Score: 20.0 / 78, Start: 0, End: 5},
+						{Applied: true, ExpectedLoc: 0, Loc: 0, Start: 0, End: 5},
+					},
+					ExpectedLoc: 0, Loc: 0, Score: 20.0 / 78, Start: 0, End: 5,
+				},
+			},
+			[]string{"xabcy"},
+		},
+		{
+			"Big delete rejected",
+			x1, "xabcy", "x12345678901234567890---------------++++++++++---------------12345678901234567890y",
+			"xabc12345678901234567890---------------++++++++++---------------12345678901234567890y",
+			[]PatchReport{
+				{
+					Parts: []PatchPart{
+						{ExpectedLoc: 0, Loc: 0, Score: 40.0 / 78, Start: -1, End: -1},
+						{Applied: true, ExpectedLoc: 0, Loc: 0, Score: 0.5, Start: 0, End: 8},
+					},
+					ExpectedLoc: 0, Loc: 0, Score: 40.0 / 78, Start: 0, End: 8,
+				},
+			},
+			[]string{"xabc1234"},
+		},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		patches := config.PatchMakeFromTexts(test.Text1, test.Text2)
+		actual, reports := config.PatchApplyReport(patches, test.TextBase)
+		msg := fmt.Sprintf("Test case #%d, %s", i, test.Name)
+		assert.Equal(t, test.Expected, actual, msg)
+		assert.Equal(t, len(test.Reports), len(reports), msg)
+		var changed []string
+		for j, r := range reports {
+			assert.InDelta(t, test.Reports[j].Score, r.Score, 1e-9, msg)
+			r.Score = test.Reports[j].Score
+			assert.Equal(t, len(test.Reports[j].Parts), len(r.Parts), msg)
+			for k := range r.Parts {
+				if k < len(test.Reports[j].Parts) {
+					assert.InDelta(t, test.Reports[j].Parts[k].Score, r.Parts[k].Score, 1e-9, msg)
+					r.Parts[k].Score = test.Reports[j].Parts[k].Score
+				}
+			}
+			assert.Equal(t, test.Reports[j], r, msg)
+			if r.Start != -1 {
+				changed = append(changed, actual[r.Start:r.End])
+			}
+		}
+		assert.Equal(t, test.Changed, changed, msg)
+		// The parts match the results of PatchApply.
+		text, applies := config.PatchApply(patches, test.TextBase)
+		assert.Equal(t, test.Expected, text, msg)
+		var parts []bool
+		for _, r := range reports {
+			for _, part := range r.Parts {
+				parts = append(parts, part.Applied)
+			}
+		}
+		assert.Equal(t, len(applies), len(parts), msg)
+		for j := range parts {
+			assert.Equal(t, applies[j], parts[j], msg)
+		}
+	}
+
+	// Context cancellation.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	text, reports, err := config.PatchApplyReportContext(ctx, config.PatchMakeFromTexts(fox1, fox2), fox1)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, "", text)
+	assert.Nil(t, reports)
+}
+
+func TestPatchApplyStrict(t *testing.T) {
+	fox1 := "The quick brown fox jumps over the lazy dog."
+	fox2 := "That quick brown fox jumped over a lazy dog."
+	tests := []struct {
+		Name     string
+		Text1    string
+		Text2    string
+		TextBase string
+		Expected string
+		Err      string
+	}{
+		{"Null case", "", "", "Hello world.", "Hello world.", ""},
+		{"Exact match", fox1, fox2, fox1, fox2, ""},
+		{"Insert into empty text", "", "Hello world.", "", "Hello world.", ""},
+		{"Append", "abc", "abcdef", "abc", "abcdef", ""},
+		{
+			"Moved text",
+			fox1, fox2, "Wow! " + fox1,
+			"Wow! " + fox1,
+			`patch 0 (@@ -1,11 +1,12 @@) does not match text at 0: expected "The quick b", found "Wow! The qu"`,
+		},
+		{
+			"Fuzzy match",
+			fox1, fox2, "The quick brown fox jumps over the tired dog.",
+			"The quick brown fox jumps over the tired dog.",
+			`patch 1 (@@ -22,18 +22,17 @@) does not match text at 21: expected "jumps over the laz", found "jumps over the tir"`,
+		},
+		{
+			"Text too short",
+			fox1, fox2, "The quick brown fox",
+			"The quick brown fox",
+			`patch 1 (@@ -22,18 +22,17 @@) does not match text at 21: expected "jumps over the laz", found ""`,
+		},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		patches := config.PatchMakeFromTexts(test.Text1, test.Text2)
+		actual, err := config.PatchApplyStrict(patches, test.TextBase)
+		msg := fmt.Sprintf("Test case #%d, %s", i, test.Name)
+		assert.Equal(t, test.Expected, actual, msg)
+		if test.Err == "" {
+			assert.Nil(t, err, msg)
+			continue
+		}
+		assert.EqualError(t, err, test.Err, msg)
+		var mismatch *PatchMismatchError
+		if assert.True(t, errors.As(err, &mismatch), msg) {
+			assert.Equal(t, patches[mismatch.Index], mismatch.Patch, msg)
+		}
+	}
+}
+
+func TestPatchApplyAll(t *testing.T) {
+	fox1 := "The quick brown fox jumps over the lazy dog."
+	fox2 := "That quick brown fox jumped over a lazy dog."
+	x1 := "x1234567890123456789012345678901234567890123456789012345678901234567890y"
+	tests := []struct {
+		Name     string
+		Text1    string
+		Text2    string
+		TextBase string
+		Expected string
+		Failed   []int
+		Err      string
+	}{
+		{"Null case", "", "", "Hello world.", "Hello world.", nil, ""},
+		{"Fuzzy match", fox1, fox2, "The quick red rabbit jumps over the tired tiger.", "That quick red rabbit jumped over a tired tiger.", nil, ""},
+		{
+			"All failed",
+			fox1, fox2, "I am the very model of a modern major general.",
+			"I am the very model of a modern major general.",
+			[]int{0, 1},
+			"2 of 2 patches failed: patch 0 (@@ -1,11 +1,12 @@) could not match near 0; patch 1 (@@ -22,18 +22,17 @@) could not match near 20",
+		},
+		{
+			"Some failed",
+			fox1, fox2, "The quick brown fox! I am the very model of a modern major general.",
+			"The quick brown fox! I am the very model of a modern major general.",
+			[]int{1},
+			"1 of 2 patches failed: patch 1 (@@ -22,18 +22,17 @@) could not match near 21",
+		},
+		{
+			"Big delete rejected",
+			x1, "xabcy", "x12345678901234567890---------------++++++++++---------------12345678901234567890y",
+			"x12345678901234567890---------------++++++++++---------------12345678901234567890y",
+			[]int{0},
+			"1 of 1 patches failed: patch 0 (@@ -1,72 +1,5 @@) matched with score 0.51, over the delete threshold of 0.50",
+		},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		patches := config.PatchMakeFromTexts(test.Text1, test.Text2)
+		actual, err := config.PatchApplyAll(patches, test.TextBase)
+		msg := fmt.Sprintf("Test case #%d, %s", i, test.Name)
+		assert.Equal(t, test.Expected, actual, msg)
+		if test.Err == "" {
+			assert.Nil(t, err, msg)
+			continue
+		}
+		assert.EqualError(t, err, test.Err, msg)
+		var applyErr *PatchApplyError
+		if assert.True(t, errors.As(err, &applyErr), msg) {
+			assert.Equal(t, len(patches), applyErr.Total, msg)
+			var failed []int
+			for _, f := range applyErr.Failures {
+				failed = append(failed, f.Index)
+				assert.Equal(t, patches[f.Index], f.Patch, msg)
+				assert.False(t, f.Report.Applied, msg)
+			}
+			assert.Equal(t, test.Failed, failed, msg)
+		}
+	}
+
+	// Context cancellation leaves the text unchanged.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	text, err := config.PatchApplyAllContext(ctx, config.PatchMakeFromTexts(fox1, fox2), fox1)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, fox1, text)
+}
+
+func TestPatchFailureReason(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Report   PatchReport
+		Expected string
+	}{
+		{
+			"Not found",
+			PatchReport{Parts: []PatchPart{{ExpectedLoc: 20, Loc: -1, Start: -1, End: -1}}, ExpectedLoc: 20, Loc: -1, Start: -1, End: -1},
+			"could not match near 20",
+		},
+		{
+			"Rejected",
+			PatchReport{Parts: []PatchPart{{ExpectedLoc: 0, Loc: 2, Score: 0.75, Start: -1, End: -1}}, ExpectedLoc: 0, Loc: 2, Score: 0.75, Start: -1, End: -1},
+			"matched with score 0.75, over the delete threshold of 0.50",
+		},
+		{
+			"Later part not found",
+			PatchReport{
+				Parts: []PatchPart{
+					{Applied: true, ExpectedLoc: 10, Loc: 12, Score: 0.6, Start: 12, End: 40},
+					{ExpectedLoc: 40, Loc: -1, Start: -1, End: -1},
+					{ExpectedLoc: 60, Loc: -1, Start: -1, End: -1},
+				},
+				ExpectedLoc: 10, Loc: 12, Score: 0.6, Start: 12, End: 40,
+			},
+			"could not match 2 of 3 parts near 40",
+		},
+		{
+			"Later part rejected",
+			PatchReport{
+				Parts: []PatchPart{
+					{Applied: true, ExpectedLoc: 0, Loc: 0, Score: 0.8, Start: 0, End: 8},
+					{ExpectedLoc: 8, Loc: 8, Score: 0.55, Start: -1, End: -1},
+				},
+				ExpectedLoc: 0, Loc: 0, Score: 0.8, Start: 0, End: 8,
+			},
+			"matched with score 0.55, over the delete threshold of 0.50",
+		},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		actual := config.patchFailureReason(test.Report)
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
+
+func TestPatchInvert(t *testing.T) {
+	config := NewDefaultConfig()
+	patches := config.PatchMakeFromTexts("The quick brown fox jumps over the lazy dog.", "That quick brown fox jumped over a lazy dog.")
+	original := config.PatchToText(patches)
+	inverse := config.PatchInvert(patches)
+	assert.Equal(t, "@@ -22,17 +22,18 @@\n jump\n-ed\n+s\n  over \n-a\n+the\n  laz\n@@ -1,12 +1,11 @@\n Th\n-at\n+e\n  quick b\n", config.PatchToText(inverse))
+	// The patches are left alone.
+	assert.Equal(t, original, config.PatchToText(patches))
+	assert.Equal(t, patches, config.PatchInvert(inverse))
+	assert.Equal(t, []Patch{}, config.PatchInvert([]Patch{}))
+
+	tests := []struct {
+		Name  string
+		Text1 string
+		Text2 string
+	}{
+		{"Empty", "", ""},
+		{"Insert all", "", "Hello world."},
+		{"Delete all", "Hello world.", ""},
+		{"Replace", "The quick brown fox jumps over the lazy dog.", "That quick brown fox jumped over a lazy dog."},
+		{"Unicode", "日本語のテキスト、そして English", "日本のテキスト、and English text"},
+		{"Long", strings.Repeat("abcdefghij", 20) + "123" + strings.Repeat("klmnopqrst", 20), "x" + strings.Repeat("abcdefghij", 20) + strings.Repeat("klmnopqrst", 20) + "y"},
+		{"Large delete", "x1234567890123456789012345678901234567890123456789012345678901234567890y", "xabcy"},
+	}
+	for i, test := range tests {
+		msg := fmt.Sprintf("Test case #%d, %s", i, test.Name)
+		patches := config.PatchMakeFromTexts(test.Text1, test.Text2)
+		text2, _ := config.PatchApply(patches, test.Text1)
+		assert.Equal(t, test.Text2, text2, msg)
+		text1, applies := config.PatchApply(config.PatchInvert(patches), text2)
+		assert.Equal(t, test.Text1, text1, msg)
+		for _, ok := range applies {
+			assert.True(t, ok, msg)
+		}
+	}
+}
+
+func BenchmarkPatchApplyLargeDelete(b *testing.B) {
+	config := NewDefaultConfig()
+	for _, n := range []int{500, 2000, 5000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			var buf strings.Builder
+			for i := 0; buf.Len() < n; i++ {
+				fmt.Fprintf(&buf, "%d ", i)
+			}
+			deleted := buf.String()[:n]
+			patches := config.PatchMakeFromTexts("<"+deleted+">", "<>")
+			// The text to delete has every third character changed.
+			changed := []byte(deleted)
+			for i := 0; i < len(changed); i += 3 {
+				changed[i] = '#'
+			}
+			text := "<" + string(changed) + ">"
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				config.PatchApply(patches, text)
+			}
+		})
+	}
+}
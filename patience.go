@@ -0,0 +1,101 @@
+package diffmatchpatch
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// diffPatience finds the differences between two texts reduced to one rune
+// per line by linesToRunes, using the patience algorithm: lines occurring
+// exactly once in each text are matched up as anchors, and the gaps between
+// them are diffed recursively.  Gaps without any such lines are diffed with
+// diffRunes.
+func (config *Config) diffPatience(ctx context.Context, text1, text2 []rune, deadline time.Time) []Diff {
+	// Trim off common prefix and suffix.
+	n := commonPrefixLength(text1, text2)
+	prefix := text1[:n]
+	text1, text2 = text1[n:], text2[n:]
+	n = commonSuffixLength(text1, text2)
+	suffix := text1[len(text1)-n:]
+	text1, text2 = text1[:len(text1)-n], text2[:len(text2)-n]
+	diffs := diffAppend(nil, Diff{OpEqual, string(prefix)})
+	if anchors := patienceAnchors(text1, text2); len(anchors) == 0 {
+		diffs = diffAppend(diffs, config.diffRunes(ctx, text1, text2, false, deadline)...)
+	} else {
+		x, y := 0, 0
+		for k := 0; k < len(anchors); {
+			a := anchors[k]
+			diffs = diffAppend(diffs, config.diffPatience(ctx, text1[x:a[0]], text2[y:a[1]], deadline)...)
+			// Take any following adjacent anchors at once.
+			n := 1
+			for k+n < len(anchors) && anchors[k+n] == [2]int{a[0] + n, a[1] + n} {
+				n++
+			}
+			diffs = diffAppend(diffs, Diff{OpEqual, string(text1[a[0] : a[0]+n])})
+			x, y = a[0]+n, a[1]+n
+			k += n
+		}
+		diffs = diffAppend(diffs, config.diffPatience(ctx, text1[x:], text2[y:], deadline)...)
+	}
+	return diffAppend(diffs, Diff{OpEqual, string(suffix)})
+}
+
+// patienceAnchors returns the index pairs of the longest sequence of lines
+// that occur exactly once in each of text1 and text2, and appear in the same
+// order in both.
+func patienceAnchors(text1, text2 []rune) [][2]int {
+	type count struct {
+		n1, n2 int
+		i2     int
+	}
+	counts := make(map[rune]*count)
+	for _, r := range text1 {
+		c := counts[r]
+		if c == nil {
+			c = new(count)
+			counts[r] = c
+		}
+		c.n1++
+	}
+	for i, r := range text2 {
+		if c := counts[r]; c != nil {
+			c.n2++
+			c.i2 = i
+		}
+	}
+	// Unique common lines, in text1 order.
+	var pairs [][2]int
+	for i, r := range text1 {
+		if c := counts[r]; c.n1 == 1 && c.n2 == 1 {
+			pairs = append(pairs, [2]int{i, c.i2})
+		}
+	}
+	// Patience sort the pairs by their text2 index.  The top of each pile
+	// links back to the top of the previous pile when placed, which gives
+	// the longest increasing subsequence.
+	var tops []int
+	prev := make([]int, len(pairs))
+	for k, p := range pairs {
+		n := sort.Search(len(tops), func(i int) bool {
+			return pairs[tops[i]][1] > p[1]
+		})
+		prev[k] = -1
+		if n != 0 {
+			prev[k] = tops[n-1]
+		}
+		if n == len(tops) {
+			tops = append(tops, k)
+		} else {
+			tops[n] = k
+		}
+	}
+	if len(tops) == 0 {
+		return nil
+	}
+	anchors := make([][2]int, len(tops))
+	for i, k := len(tops)-1, tops[len(tops)-1]; i >= 0; i, k = i-1, prev[k] {
+		anchors[i] = pairs[k]
+	}
+	return anchors
+}
@@ -0,0 +1,54 @@
+package diffmatchpatch
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatienceAnchors(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Text1    string
+		Text2    string
+		Expected [][2]int
+	}{
+		{"Empty", "", "", nil},
+		{"No common lines", "abc", "xyz", nil},
+		{"Repeated lines are not anchors", "abab", "baba", nil},
+		{"Unique lines", "axbyc", "xzy", [][2]int{{1, 0}, {3, 2}}},
+		{"Longest increasing sequence", "abcdef", "dabcfe", [][2]int{{0, 1}, {1, 2}, {2, 3}, {5, 4}}},
+	}
+	for i, test := range tests {
+		actual := patienceAnchors([]rune(test.Text1), []rune(test.Text2))
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
+
+func TestDiffPatience(t *testing.T) {
+	text1 := "func f0() {\n\tx0()\n}\n\nfunc f2() {\n\tx0()\n\tx0()\n}\n\nfunc f4() {\n\tx3()\n}\n\n"
+	text2 := "func f4() {\n\tx3()\n}\n\nfunc f0() {\n\tx0()\n}\n\n"
+	config := NewDefaultConfig()
+	// Myers matches up the braces and blank lines.
+	assert.Equal(t, "--- a\n+++ b\n@@ -1,13 +1,8 @@\n-func f0() {\n-\tx0()\n+func f4() {\n+\tx3()\n }\n \n-func f2() {\n+func f0() {\n \tx0()\n-\tx0()\n-}\n-\n-func f4() {\n-\tx3()\n }\n \n", config.DiffUnified("a", "b", text1, text2))
+	config.DiffAlgorithm = AlgorithmPatience
+	assert.Equal(t, "--- a\n+++ b\n@@ -1,13 +1,8 @@\n-func f0() {\n-\tx0()\n-}\n-\n-func f2() {\n-\tx0()\n-\tx0()\n-}\n-\n func f4() {\n \tx3()\n }\n \n+func f0() {\n+\tx0()\n+}\n+\n", config.DiffUnified("a", "b", text1, text2))
+	assert.Equal(t, []Diff{
+		{OpDelete, "func f0() {\n\tx0()\n}\n\nfunc f2() {\n\tx0()\n\tx0()\n}\n\n"},
+		{OpEqual, "func f4() {\n\tx3()\n}\n\n"},
+		{OpInsert, "func f0() {\n\tx0()\n}\n\n"},
+	}, config.Diff(text1, text2, false))
+	// Replaced lines are rediffed character by character.
+	assert.Equal(t, []Diff{
+		{OpEqual, "alpha\nbet"},
+		{OpDelete, "a"},
+		{OpInsert, "h"},
+		{OpEqual, "\ngamma\n"},
+	}, config.Diff("alpha\nbeta\ngamma\n", "alpha\nbeth\ngamma\n", false))
+	// Gaps without unique lines fall back to Myers.
+	assert.Equal(t, []Diff{
+		{OpEqual, "x\ny\n"},
+		{OpInsert, "x\n"},
+	}, config.Diff("x\ny\n", "x\ny\nx\n", false))
+}
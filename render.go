@@ -0,0 +1,401 @@
+package diffmatchpatch
+
+import (
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Renderer renders diffs to a writer, through hooks called by Render and
+// RenderHunks.  For each hunk, StartHunk is called, then each run of equal,
+// deleted or inserted text is rendered with StartRun, Text and Newline for
+// each line of the run, and EndRun, and finally EndHunk is called.
+//
+// Renderers may keep state between calls, so they are not safe for
+// concurrent use unless documented otherwise.
+type Renderer interface {
+	// StartHunk starts a hunk.
+	StartHunk(w io.Writer, h Hunk) error
+	// EndHunk ends a hunk.
+	EndHunk(w io.Writer, h Hunk) error
+	// StartRun starts a run of text with op.
+	StartRun(w io.Writer, op Op) error
+	// EndRun ends a run of text with op.
+	EndRun(w io.Writer, op Op) error
+	// Text renders part of a line of a run, which is never empty and never
+	// contains a newline.
+	Text(w io.Writer, op Op, text string) error
+	// Newline renders the end of a line of a run.
+	Newline(w io.Writer, op Op) error
+}
+
+// Hunk is a part of the diffs rendered by a Renderer.
+type Hunk struct {
+	// Start1 and Start2 are the 0-based first lines of the hunk in the source
+	// and destination texts, and Length1 and Length2 the number of lines.
+	Start1, Length1 int
+	Start2, Length2 int
+	// Diffs are the diffs in the hunk.
+	Diffs []Diff
+}
+
+// Render renders diffs with r to w, as a single hunk.
+func (config *Config) Render(w io.Writer, r Renderer, diffs []Diff) error {
+	return renderHunk(w, r, Hunk{
+		Length1: countLines(config.DiffText1(diffs)),
+		Length2: countLines(config.DiffText2(diffs)),
+		Diffs:   diffs,
+	})
+}
+
+// RenderHunks renders the changed lines of diffs with r to w, as hunks
+// including UnifiedContext unchanged lines around each change.  Changes
+// separated by no more than twice that many unchanged lines share a hunk.
+// Nothing is rendered when the texts are equal.
+//
+// Diffs that do not fall on line boundaries (e.g. a character diff) are
+// re-diffed line by line first.
+func (config *Config) RenderHunks(w io.Writer, r Renderer, diffs []Diff) error {
+	var lines []unifiedLine
+	for _, d := range config.lineAligned(diffs) {
+		for _, line := range splitLines(d.Text) {
+			lines = append(lines, unifiedLine{d.Op, line})
+		}
+	}
+	// Count the lines of each text before each line.
+	count1, count2 := make([]int, len(lines)+1), make([]int, len(lines)+1)
+	for i, l := range lines {
+		count1[i+1], count2[i+1] = count1[i], count2[i]
+		if l.Op != OpInsert {
+			count1[i+1]++
+		}
+		if l.Op != OpDelete {
+			count2[i+1]++
+		}
+	}
+	n := max(0, config.UnifiedContext)
+	for i := 0; i < len(lines); {
+		// Find the next change.
+		for i < len(lines) && lines[i].Op == OpEqual {
+			i++
+		}
+		if i == len(lines) {
+			break
+		}
+		start := max(0, i-n)
+		// Extend the hunk over changes separated by no more than 2n lines.
+		end := i
+		for {
+			for end < len(lines) && lines[end].Op != OpEqual {
+				end++
+			}
+			j := end
+			for j < len(lines) && lines[j].Op == OpEqual {
+				j++
+			}
+			if j == len(lines) || j-end > 2*n {
+				break
+			}
+			end = j
+		}
+		end = min(len(lines), end+n)
+		h := Hunk{
+			Start1:  count1[start],
+			Length1: count1[end] - count1[start],
+			Start2:  count2[start],
+			Length2: count2[end] - count2[start],
+		}
+		for _, l := range lines[start:end] {
+			h.Diffs = diffAppend(h.Diffs, Diff{l.Op, l.Text})
+		}
+		if err := renderHunk(w, r, h); err != nil {
+			return err
+		}
+		i = end
+	}
+	return nil
+}
+
+// renderHunk renders a hunk with r to w.
+func renderHunk(w io.Writer, r Renderer, h Hunk) error {
+	if err := r.StartHunk(w, h); err != nil {
+		return err
+	}
+	for _, d := range h.Diffs {
+		if err := r.StartRun(w, d.Op); err != nil {
+			return err
+		}
+		for text := d.Text; len(text) != 0; {
+			i := strings.IndexByte(text, '\n')
+			if i == -1 {
+				i = len(text)
+			}
+			if i != 0 {
+				if err := r.Text(w, d.Op, text[:i]); err != nil {
+					return err
+				}
+			}
+			if i == len(text) {
+				break
+			}
+			if err := r.Newline(w, d.Op); err != nil {
+				return err
+			}
+			text = text[i+1:]
+		}
+		if err := r.EndRun(w, d.Op); err != nil {
+			return err
+		}
+	}
+	return r.EndHunk(w, h)
+}
+
+// countLines returns the number of lines of text, counting a final line
+// without a newline.
+func countLines(text string) int {
+	n := strings.Count(text, "\n")
+	if len(text) != 0 && !strings.HasSuffix(text, "\n") {
+		n++
+	}
+	return n
+}
+
+// NopRenderer is a Renderer that renders nothing, for embedding in renderers
+// that only need some of the hooks.
+type NopRenderer struct{}
+
+// StartHunk satisfies the Renderer interface.
+func (NopRenderer) StartHunk(io.Writer, Hunk) error { return nil }
+
+// EndHunk satisfies the Renderer interface.
+func (NopRenderer) EndHunk(io.Writer, Hunk) error { return nil }
+
+// StartRun satisfies the Renderer interface.
+func (NopRenderer) StartRun(io.Writer, Op) error { return nil }
+
+// EndRun satisfies the Renderer interface.
+func (NopRenderer) EndRun(io.Writer, Op) error { return nil }
+
+// Text satisfies the Renderer interface.
+func (NopRenderer) Text(io.Writer, Op, string) error { return nil }
+
+// Newline satisfies the Renderer interface.
+func (NopRenderer) Newline(io.Writer, Op) error { return nil }
+
+// HtmlRenderer renders diffs as the HTML of DiffPrettyHtml: runs in <ins>,
+// <del> and <span> elements, with newlines shown as "&para;<br>".
+type HtmlRenderer struct {
+	NopRenderer
+}
+
+// StartRun satisfies the Renderer interface.
+func (HtmlRenderer) StartRun(w io.Writer, op Op) error {
+	var err error
+	switch op {
+	case OpInsert:
+		_, err = io.WriteString(w, "<ins style=\"background:#e6ffe6;\">")
+	case OpDelete:
+		_, err = io.WriteString(w, "<del style=\"background:#ffe6e6;\">")
+	case OpEqual:
+		_, err = io.WriteString(w, "<span>")
+	}
+	return err
+}
+
+// EndRun satisfies the Renderer interface.
+func (HtmlRenderer) EndRun(w io.Writer, op Op) error {
+	var err error
+	switch op {
+	case OpInsert:
+		_, err = io.WriteString(w, "</ins>")
+	case OpDelete:
+		_, err = io.WriteString(w, "</del>")
+	case OpEqual:
+		_, err = io.WriteString(w, "</span>")
+	}
+	return err
+}
+
+// Text satisfies the Renderer interface.
+func (HtmlRenderer) Text(w io.Writer, op Op, text string) error {
+	_, err := io.WriteString(w, html.EscapeString(text))
+	return err
+}
+
+// Newline satisfies the Renderer interface.
+func (HtmlRenderer) Newline(w io.Writer, op Op) error {
+	_, err := io.WriteString(w, "&para;<br>")
+	return err
+}
+
+// TextRenderer renders diffs as the text of DiffPrettyText: the text of
+// both texts, with insertions in green and deletions in red.
+type TextRenderer struct {
+	NopRenderer
+}
+
+// StartRun satisfies the Renderer interface.
+func (TextRenderer) StartRun(w io.Writer, op Op) error {
+	var err error
+	switch op {
+	case OpInsert:
+		_, err = io.WriteString(w, "\x1b[32m")
+	case OpDelete:
+		_, err = io.WriteString(w, "\x1b[31m")
+	}
+	return err
+}
+
+// EndRun satisfies the Renderer interface.
+func (TextRenderer) EndRun(w io.Writer, op Op) error {
+	if op == OpEqual {
+		return nil
+	}
+	_, err := io.WriteString(w, "\x1b[0m")
+	return err
+}
+
+// Text satisfies the Renderer interface.
+func (TextRenderer) Text(w io.Writer, op Op, text string) error {
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+// Newline satisfies the Renderer interface.
+func (TextRenderer) Newline(w io.Writer, op Op) error {
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// DeltaRenderer renders diffs in the delta format of DiffToDelta.
+type DeltaRenderer struct {
+	NopRenderer
+	// The number of runs rendered, and the number of runes in the current
+	// run.
+	runs, n int
+}
+
+// StartHunk satisfies the Renderer interface.
+func (r *DeltaRenderer) StartHunk(w io.Writer, h Hunk) error {
+	r.runs = 0
+	return nil
+}
+
+// StartRun satisfies the Renderer interface.
+func (r *DeltaRenderer) StartRun(w io.Writer, op Op) error {
+	token := "="
+	switch op {
+	case OpInsert:
+		token = "+"
+	case OpDelete:
+		token = "-"
+	}
+	// Operations are tab separated.
+	if r.runs != 0 {
+		token = "\t" + token
+	}
+	r.runs, r.n = r.runs+1, 0
+	_, err := io.WriteString(w, token)
+	return err
+}
+
+// EndRun satisfies the Renderer interface.
+func (r *DeltaRenderer) EndRun(w io.Writer, op Op) error {
+	if op == OpInsert {
+		return nil
+	}
+	_, err := io.WriteString(w, strconv.Itoa(r.n))
+	return err
+}
+
+// Text satisfies the Renderer interface.
+func (r *DeltaRenderer) Text(w io.Writer, op Op, text string) error {
+	if op != OpInsert {
+		r.n += utf8.RuneCountInString(text)
+		return nil
+	}
+	_, err := io.WriteString(w, encodeURI(text))
+	return err
+}
+
+// Newline satisfies the Renderer interface.
+func (r *DeltaRenderer) Newline(w io.Writer, op Op) error {
+	if op != OpInsert {
+		r.n++
+		return nil
+	}
+	_, err := io.WriteString(w, "%0A")
+	return err
+}
+
+// UnifiedRenderer renders hunks in the unified diff format, as written by
+// DiffUnified, for use with RenderHunks.  Name1 and Name2 are written in
+// the "---" and "+++" headers before the first hunk.
+type UnifiedRenderer struct {
+	NopRenderer
+	Name1, Name2 string
+	// Whether the headers have been written, and whether a line has been
+	// started.
+	header, line bool
+}
+
+// StartHunk satisfies the Renderer interface.
+func (r *UnifiedRenderer) StartHunk(w io.Writer, h Hunk) error {
+	var s string
+	if !r.header {
+		r.header = true
+		s = "--- " + r.Name1 + "\n+++ " + r.Name2 + "\n"
+	}
+	s += "@@ -" + unifiedCoords(h.Start1, h.Length1) + " +" + unifiedCoords(h.Start2, h.Length2) + " @@\n"
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// EndRun satisfies the Renderer interface.
+func (r *UnifiedRenderer) EndRun(w io.Writer, op Op) error {
+	if !r.line {
+		return nil
+	}
+	r.line = false
+	_, err := io.WriteString(w, "\n\\ No newline at end of file\n")
+	return err
+}
+
+// Text satisfies the Renderer interface.
+func (r *UnifiedRenderer) Text(w io.Writer, op Op, text string) error {
+	if err := r.startLine(w, op); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+// Newline satisfies the Renderer interface.
+func (r *UnifiedRenderer) Newline(w io.Writer, op Op) error {
+	if err := r.startLine(w, op); err != nil {
+		return err
+	}
+	r.line = false
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// startLine writes the gutter of a line of op, unless the line has been
+// started.
+func (r *UnifiedRenderer) startLine(w io.Writer, op Op) error {
+	if r.line {
+		return nil
+	}
+	r.line = true
+	gutter := " "
+	switch op {
+	case OpInsert:
+		gutter = "+"
+	case OpDelete:
+		gutter = "-"
+	}
+	_, err := io.WriteString(w, gutter)
+	return err
+}
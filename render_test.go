@@ -0,0 +1,199 @@
+package diffmatchpatch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordRenderer records the hooks called.
+type recordRenderer struct {
+	calls []string
+}
+
+func (r *recordRenderer) StartHunk(w io.Writer, h Hunk) error {
+	r.calls = append(r.calls, fmt.Sprintf("hunk %d,%d %d,%d", h.Start1, h.Length1, h.Start2, h.Length2))
+	return nil
+}
+
+func (r *recordRenderer) EndHunk(w io.Writer, h Hunk) error {
+	r.calls = append(r.calls, "end hunk")
+	return nil
+}
+
+func (r *recordRenderer) StartRun(w io.Writer, op Op) error {
+	r.calls = append(r.calls, "run "+op.String())
+	return nil
+}
+
+func (r *recordRenderer) EndRun(w io.Writer, op Op) error {
+	r.calls = append(r.calls, "end run")
+	return nil
+}
+
+func (r *recordRenderer) Text(w io.Writer, op Op, text string) error {
+	r.calls = append(r.calls, fmt.Sprintf("text %q", text))
+	return nil
+}
+
+func (r *recordRenderer) Newline(w io.Writer, op Op) error {
+	r.calls = append(r.calls, "newline")
+	return nil
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Diffs    []Diff
+		Expected []string
+	}{
+		{"Empty", nil, []string{"hunk 0,0 0,0", "end hunk"}},
+		{
+			"Runs",
+			[]Diff{{OpEqual, "a\nb"}, {OpDelete, "c\n\n"}, {OpInsert, ""}},
+			[]string{
+				"hunk 0,3 0,2",
+				"run Equal", `text "a"`, "newline", `text "b"`, "end run",
+				"run Delete", `text "c"`, "newline", "newline", "end run",
+				"run Insert", "end run",
+				"end hunk",
+			},
+		},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		r := new(recordRenderer)
+		assert.NoError(t, config.Render(io.Discard, r, test.Diffs), fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		assert.Equal(t, test.Expected, r.calls, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
+
+func TestRenderHunks(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Diffs    []Diff
+		Context  int
+		Expected []string
+	}{
+		{"Equal", []Diff{{OpEqual, "a\nb\n"}}, 3, nil},
+		{
+			"Hunks",
+			[]Diff{{OpEqual, "1\n2\n3\n4\n"}, {OpDelete, "5\n"}, {OpInsert, "five\n"}, {OpEqual, "6\n7\n8\n9\n"}, {OpInsert, "10\n"}},
+			1,
+			[]string{
+				"hunk 3,3 3,3",
+				"run Equal", `text "4"`, "newline", "end run",
+				"run Delete", `text "5"`, "newline", "end run",
+				"run Insert", `text "five"`, "newline", "end run",
+				"run Equal", `text "6"`, "newline", "end run",
+				"end hunk",
+				"hunk 8,1 8,2",
+				"run Equal", `text "9"`, "newline", "end run",
+				"run Insert", `text "10"`, "newline", "end run",
+				"end hunk",
+			},
+		},
+		{
+			"Character diff",
+			[]Diff{{OpEqual, "a\nb"}, {OpInsert, "c"}, {OpEqual, "\n"}},
+			0,
+			[]string{
+				"hunk 1,1 1,1",
+				"run Delete", `text "b"`, "newline", "end run",
+				"run Insert", `text "bc"`, "newline", "end run",
+				"end hunk",
+			},
+		},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		config.UnifiedContext = test.Context
+		r := new(recordRenderer)
+		assert.NoError(t, config.RenderHunks(io.Discard, r, test.Diffs), fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		assert.Equal(t, test.Expected, r.calls, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
+
+// markdownRenderer renders diffs as Markdown, as an example of a renderer
+// only implementing some of the hooks.
+type markdownRenderer struct {
+	NopRenderer
+}
+
+func (markdownRenderer) StartRun(w io.Writer, op Op) error {
+	_, err := io.WriteString(w, map[Op]string{OpDelete: "~~", OpInsert: "**"}[op])
+	return err
+}
+
+func (r markdownRenderer) EndRun(w io.Writer, op Op) error {
+	return r.StartRun(w, op)
+}
+
+func (markdownRenderer) Text(w io.Writer, op Op, text string) error {
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+func TestRenderers(t *testing.T) {
+	diffs := []Diff{{OpEqual, "a\n<b>"}, {OpDelete, "c "}, {OpInsert, "d!\n"}}
+	tests := []struct {
+		Name     string
+		Renderer Renderer
+		Expected string
+	}{
+		{"Nop", NopRenderer{}, ""},
+		{"Markdown", markdownRenderer{}, "a<b>~~c ~~**d!**"},
+		{
+			"Html",
+			HtmlRenderer{},
+			`<span>a&para;<br>&lt;b&gt;</span><del style="background:#ffe6e6;">c </del><ins style="background:#e6ffe6;">d!&para;<br></ins>`,
+		},
+		{"Text", TextRenderer{}, "a\n<b>\x1b[31mc \x1b[0m\x1b[32md!\n\x1b[0m"},
+		{"Delta", &DeltaRenderer{}, "=5\t-2\t+d!%0A"},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		var buf bytes.Buffer
+		assert.NoError(t, config.Render(&buf, test.Renderer, diffs), fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		assert.Equal(t, test.Expected, buf.String(), fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+	// Renderers can be reused.
+	r := &DeltaRenderer{}
+	var buf bytes.Buffer
+	assert.NoError(t, config.Render(&buf, r, diffs))
+	buf.Reset()
+	assert.NoError(t, config.Render(&buf, r, diffs))
+	assert.Equal(t, "=5\t-2\t+d!%0A", buf.String())
+}
+
+// errWriter fails after writing n bytes.
+type errWriter struct {
+	n int
+}
+
+func (w *errWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, errors.New("write failed")
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestRenderError(t *testing.T) {
+	config := NewDefaultConfig()
+	diffs := []Diff{{OpEqual, "a\nb\n"}, {OpDelete, "c\n"}, {OpInsert, "d\n"}}
+	text := config.DiffToUnified("x", "y", diffs)
+	for n := 0; n < len(text); n++ {
+		err := config.RenderHunks(&errWriter{n}, &UnifiedRenderer{Name1: "x", Name2: "y"}, diffs)
+		assert.EqualError(t, err, "write failed", fmt.Sprintf("n = %d", n))
+	}
+	assert.NoError(t, config.RenderHunks(&errWriter{len(text)}, &UnifiedRenderer{Name1: "x", Name2: "y"}, diffs))
+	assert.True(t, strings.HasPrefix(text, "--- x\n+++ y\n@@ -1,3 +1,3 @@\n"))
+}
@@ -0,0 +1,238 @@
+package diffmatchpatch
+
+import (
+	"bytes"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// SideBySideHtmlStyle is the style sheet used by the standalone page written
+// by DiffSideBySideHtml, for use with the table it writes otherwise.
+const SideBySideHtmlStyle = `table.dmp-diff { border-collapse: collapse; font-family: monospace; width: 100%; }
+table.dmp-diff td { padding: 0 4px; vertical-align: top; white-space: pre-wrap; }
+table.dmp-diff td.dmp-num { color: #888; text-align: right; user-select: none; width: 1%; }
+table.dmp-diff td.dmp-delete { background: #ffecec; }
+table.dmp-diff td.dmp-insert { background: #eaffea; }
+table.dmp-diff td.dmp-empty { background: #f4f4f4; }
+table.dmp-diff del { background: #ffb6b6; text-decoration: none; }
+table.dmp-diff ins { background: #a6f3a6; text-decoration: none; }
+table.dmp-diff tbody.dmp-fold td { background: #f0f4ff; color: #555; cursor: pointer; text-align: center; }
+`
+
+// sideBySideHtmlScript expands and collapses folded lines when their fold
+// is clicked.
+const sideBySideHtmlScript = `document.querySelectorAll("tbody.dmp-fold").forEach(function(fold) {
+  fold.addEventListener("click", function() {
+    fold.nextElementSibling.hidden = !fold.nextElementSibling.hidden;
+  });
+});
+`
+
+// DiffSideBySideHtml converts a []Diff into a two column HTML table, with
+// the lines of the source text on the left and the lines of the destination
+// text on the right, each numbered.  Changed lines are paired up, and the
+// changes within each pair are marked with <del> and <ins>.
+//
+// Runs of unchanged lines further than UnifiedContext lines from a change
+// are folded into a hidden <tbody class="dmp-folded">, preceded by a
+// <tbody class="dmp-fold"> row saying how many lines are folded.  Cells are
+// styled through classes, see SideBySideHtmlStyle.
+//
+// When page is true, a complete HTML page is returned, with the style sheet
+// and a script expanding folded lines when clicked.
+func (config *Config) DiffSideBySideHtml(diffs []Diff, page bool) string {
+	var buf bytes.Buffer
+	if page {
+		_, _ = buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Diff</title>\n<style>\n")
+		_, _ = buf.WriteString(SideBySideHtmlStyle)
+		_, _ = buf.WriteString("</style>\n</head>\n<body>\n")
+	}
+	_, _ = buf.WriteString("<table class=\"dmp-diff\">\n")
+	for _, block := range foldRows(config.sideBySideRows(diffs), config.UnifiedContext) {
+		if block.Folded {
+			_, _ = buf.WriteString("<tbody class=\"dmp-fold\"><tr><td colspan=\"4\">")
+			_, _ = buf.WriteString(foldedLines(len(block.Rows)))
+			_, _ = buf.WriteString("</td></tr></tbody>\n<tbody class=\"dmp-folded\" hidden>\n")
+		} else {
+			_, _ = buf.WriteString("<tbody>\n")
+		}
+		for _, row := range block.Rows {
+			_, _ = buf.WriteString("<tr>")
+			sideBySideHtmlCell(&buf, row.Left, row.Changed, "dmp-delete", "del")
+			sideBySideHtmlCell(&buf, row.Right, row.Changed, "dmp-insert", "ins")
+			_, _ = buf.WriteString("</tr>\n")
+		}
+		_, _ = buf.WriteString("</tbody>\n")
+	}
+	_, _ = buf.WriteString("</table>\n")
+	if page {
+		_, _ = buf.WriteString("<script>\n")
+		_, _ = buf.WriteString(sideBySideHtmlScript)
+		_, _ = buf.WriteString("</script>\n</body>\n</html>\n")
+	}
+	return buf.String()
+}
+
+// sideBySideHtmlCell writes the line number and text cells of one side of a
+// row.  class is the class of changed lines, and tag marks the changes
+// within them.
+func sideBySideHtmlCell(buf *bytes.Buffer, line sideLine, changed bool, class, tag string) {
+	if line.Num == 0 {
+		_, _ = buf.WriteString("<td class=\"dmp-num dmp-empty\"></td><td class=\"dmp-empty\"></td>")
+		return
+	}
+	_, _ = buf.WriteString("<td class=\"dmp-num\">" + strconv.Itoa(line.Num) + "</td>")
+	if !changed {
+		class = "dmp-equal"
+	}
+	_, _ = buf.WriteString("<td class=\"" + class + "\">")
+	for _, d := range line.Diffs {
+		text := html.EscapeString(d.Text)
+		if d.Op == OpEqual {
+			_, _ = buf.WriteString(text)
+			continue
+		}
+		_, _ = buf.WriteString("<" + tag + ">" + text + "</" + tag + ">")
+	}
+	_, _ = buf.WriteString("</td>")
+}
+
+// foldedLines describes n folded lines.
+func foldedLines(n int) string {
+	if n == 1 {
+		return "1 unchanged line"
+	}
+	return strconv.Itoa(n) + " unchanged lines"
+}
+
+// sideLine is one side of a sideRow.
+type sideLine struct {
+	// Num is the 1-based line number, or 0 when the side is empty.
+	Num int
+	// Diffs is the text of the line without its newline.  Changes within a
+	// changed line are deletions on the left, and insertions on the right.
+	Diffs []Diff
+}
+
+// sideRow is a row of a side-by-side diff.
+type sideRow struct {
+	Changed     bool
+	Left, Right sideLine
+}
+
+// sideBySideRows splits diffs into rows of a side-by-side diff.  Within each
+// run of changed lines, deleted lines are paired with inserted lines in
+// order, and each pair is diffed to find the changes within the lines.
+//
+// Diffs that do not fall on line boundaries (e.g. a character diff) are
+// re-diffed line by line first.
+func (config *Config) sideBySideRows(diffs []Diff) []sideRow {
+	diffs = config.lineAligned(diffs)
+	var rows []sideRow
+	num1, num2 := 0, 0
+	var deleted, inserted []string
+	flush := func() {
+		for i := 0; i < len(deleted) || i < len(inserted); i++ {
+			row := sideRow{Changed: true}
+			switch {
+			case i < len(deleted) && i < len(inserted):
+				line := config.Diff(deleted[i], inserted[i], false)
+				line = config.DiffCleanupSemantic(line)
+				for _, d := range line {
+					if d.Op != OpInsert {
+						row.Left.Diffs = append(row.Left.Diffs, d)
+					}
+					if d.Op != OpDelete {
+						row.Right.Diffs = append(row.Right.Diffs, d)
+					}
+				}
+			case i < len(deleted):
+				row.Left.Diffs = []Diff{{OpEqual, deleted[i]}}
+			default:
+				row.Right.Diffs = []Diff{{OpEqual, inserted[i]}}
+			}
+			if i < len(deleted) {
+				num1++
+				row.Left.Num = num1
+			}
+			if i < len(inserted) {
+				num2++
+				row.Right.Num = num2
+			}
+			rows = append(rows, row)
+		}
+		deleted, inserted = nil, nil
+	}
+	for _, d := range diffs {
+		for _, line := range splitLines(d.Text) {
+			line = strings.TrimSuffix(line, "\n")
+			switch d.Op {
+			case OpDelete:
+				deleted = append(deleted, line)
+			case OpInsert:
+				inserted = append(inserted, line)
+			case OpEqual:
+				flush()
+				num1++
+				num2++
+				rows = append(rows, sideRow{
+					Left:  sideLine{num1, []Diff{{OpEqual, line}}},
+					Right: sideLine{num2, []Diff{{OpEqual, line}}},
+				})
+			}
+		}
+	}
+	flush()
+	return rows
+}
+
+// sideBlock is a run of rows of a side-by-side diff, which may be folded.
+type sideBlock struct {
+	Folded bool
+	Rows   []sideRow
+}
+
+// foldRows splits rows into blocks, folding the unchanged rows further than
+// n rows from a changed row.
+func foldRows(rows []sideRow, n int) []sideBlock {
+	n = max(0, n)
+	var blocks []sideBlock
+	// The start of the last block.
+	last := 0
+	add := func(folded bool, start, end int) {
+		if start == end {
+			return
+		}
+		if k := len(blocks); k != 0 && !blocks[k-1].Folded && !folded {
+			blocks[k-1].Rows = rows[last:end]
+			return
+		}
+		blocks, last = append(blocks, sideBlock{folded, rows[start:end]}), start
+	}
+	for i := 0; i < len(rows); {
+		// Find the next run of unchanged rows.
+		j := i
+		for j < len(rows) && rows[j].Changed {
+			j++
+		}
+		add(false, i, j)
+		i = j
+		for j < len(rows) && !rows[j].Changed {
+			j++
+		}
+		// Keep n rows after the previous change and before the next.
+		start, end := i, j
+		if i != 0 {
+			start = min(j, i+n)
+		}
+		if j != len(rows) {
+			end = max(start, j-n)
+		}
+		add(false, i, start)
+		add(true, start, end)
+		add(false, end, j)
+		i = j
+	}
+	return blocks
+}
@@ -0,0 +1,133 @@
+package diffmatchpatch
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSideBySideRows(t *testing.T) {
+	eq := func(text string) []Diff { return []Diff{{OpEqual, text}} }
+	tests := []struct {
+		Name     string
+		Diffs    []Diff
+		Expected []sideRow
+	}{
+		{"Empty", nil, nil},
+		{
+			"Equal",
+			[]Diff{{OpEqual, "a\nb"}},
+			[]sideRow{
+				{false, sideLine{1, eq("a")}, sideLine{1, eq("a")}},
+				{false, sideLine{2, eq("b")}, sideLine{2, eq("b")}},
+			},
+		},
+		{
+			"Changed line",
+			[]Diff{{OpEqual, "a\n"}, {OpDelete, "bad cat\n"}, {OpInsert, "bad dog\n"}},
+			[]sideRow{
+				{false, sideLine{1, eq("a")}, sideLine{1, eq("a")}},
+				{
+					true,
+					sideLine{2, []Diff{{OpEqual, "bad "}, {OpDelete, "cat"}}},
+					sideLine{2, []Diff{{OpEqual, "bad "}, {OpInsert, "dog"}}},
+				},
+			},
+		},
+		{
+			"Unpaired lines",
+			[]Diff{{OpDelete, "a\nb\n"}, {OpInsert, "c\n"}, {OpEqual, "d\n"}, {OpInsert, "e\n"}},
+			[]sideRow{
+				{true, sideLine{1, []Diff{{OpDelete, "a"}}}, sideLine{1, []Diff{{OpInsert, "c"}}}},
+				{true, sideLine{2, eq("b")}, sideLine{}},
+				{false, sideLine{3, eq("d")}, sideLine{2, eq("d")}},
+				{true, sideLine{}, sideLine{3, eq("e")}},
+			},
+		},
+		{
+			"Character diff",
+			[]Diff{{OpEqual, "one\nt"}, {OpDelete, "w"}, {OpInsert, "o"}, {OpEqual, "o\n"}},
+			[]sideRow{
+				{false, sideLine{1, eq("one")}, sideLine{1, eq("one")}},
+				{
+					true,
+					sideLine{2, []Diff{{OpEqual, "t"}, {OpDelete, "w"}, {OpEqual, "o"}}},
+					sideLine{2, []Diff{{OpEqual, "t"}, {OpInsert, "o"}, {OpEqual, "o"}}},
+				},
+			},
+		},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		actual := config.sideBySideRows(test.Diffs)
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
+
+func TestFoldRows(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Changed  string
+		N        int
+		Expected string
+	}{
+		{"Empty", "", 3, ""},
+		{"Unchanged", "....", 3, "(....)"},
+		{"All changed", "xx", 3, "xx"},
+		{"Short runs", "..x..x..", 2, "..x..x.."},
+		{"Long runs", "......x....x......", 2, "(....)..x....x..(....)"},
+		{"Between changes", "x......x", 2, "x..(..)..x"},
+		{"No context", "..x..", 0, "(..)x(..)"},
+		{"Negative context", "..x..", -1, "(..)x(..)"},
+	}
+	for i, test := range tests {
+		var rows []sideRow
+		for _, c := range test.Changed {
+			rows = append(rows, sideRow{Changed: c == 'x'})
+		}
+		var actual strings.Builder
+		for _, block := range foldRows(rows, test.N) {
+			if block.Folded {
+				_, _ = actual.WriteString("(")
+			}
+			for _, row := range block.Rows {
+				if row.Changed {
+					_, _ = actual.WriteString("x")
+				} else {
+					_, _ = actual.WriteString(".")
+				}
+			}
+			if block.Folded {
+				_, _ = actual.WriteString(")")
+			}
+		}
+		assert.Equal(t, test.Expected, actual.String(), fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
+
+func TestDiffSideBySideHtml(t *testing.T) {
+	config := NewDefaultConfig()
+	config.UnifiedContext = 1
+	diffs := []Diff{{OpEqual, "a\nb\nc\n"}, {OpDelete, "<x>\n"}, {OpInsert, "<y>\nz\n"}}
+	assert.Equal(t, `<table class="dmp-diff">
+<tbody class="dmp-fold"><tr><td colspan="4">2 unchanged lines</td></tr></tbody>
+<tbody class="dmp-folded" hidden>
+<tr><td class="dmp-num">1</td><td class="dmp-equal">a</td><td class="dmp-num">1</td><td class="dmp-equal">a</td></tr>
+<tr><td class="dmp-num">2</td><td class="dmp-equal">b</td><td class="dmp-num">2</td><td class="dmp-equal">b</td></tr>
+</tbody>
+<tbody>
+<tr><td class="dmp-num">3</td><td class="dmp-equal">c</td><td class="dmp-num">3</td><td class="dmp-equal">c</td></tr>
+<tr><td class="dmp-num">4</td><td class="dmp-delete">&lt;<del>x</del>&gt;</td><td class="dmp-num">4</td><td class="dmp-insert">&lt;<ins>y</ins>&gt;</td></tr>
+<tr><td class="dmp-num dmp-empty"></td><td class="dmp-empty"></td><td class="dmp-num">5</td><td class="dmp-insert">z</td></tr>
+</tbody>
+</table>
+`, config.DiffSideBySideHtml(diffs, false))
+	// Standalone page.
+	page := config.DiffSideBySideHtml(diffs, true)
+	assert.True(t, strings.HasPrefix(page, "<!DOCTYPE html>\n"))
+	assert.Contains(t, page, SideBySideHtmlStyle)
+	assert.Contains(t, page, config.DiffSideBySideHtml(diffs, false))
+	assert.True(t, strings.HasSuffix(page, "</html>\n"))
+}
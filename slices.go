@@ -0,0 +1,131 @@
+package diffmatchpatch
+
+// Edit is a run of items of a slice that are kept, deleted or inserted.
+type Edit[T any] struct {
+	Op    Op
+	Items []T
+}
+
+// DiffSlices finds the differences between two slices of comparable items,
+// such as records, tokens or log entries.  Each distinct item is reduced to a
+// rune, in the same way as lines in line mode, and the runes are diffed with
+// the default config, without a timeout unless opts set one.  Equal edits
+// hold the items of a.
+func DiffSlices[T comparable](a, b []T, opts ...Option) []Edit[T] {
+	classes := map[T]rune{}
+	return diffSlices(a, b, func(item T) (rune, bool) {
+		r, ok := classes[item]
+		if !ok {
+			if len(classes) >= maxLineIndex-3 {
+				return 0, false
+			}
+			r = lineRune(len(classes) + 1)
+			classes[item] = r
+		}
+		return r, true
+	}, func(x, y T) bool {
+		return x == y
+	}, opts)
+}
+
+// DiffSlicesFunc finds the differences between two slices, like DiffSlices,
+// comparing items with eq, which must be an equivalence relation.  As items
+// can't be hashed, each item is compared against one item of every distinct
+// class seen so far, so eq is called O(n*k) times for n items and k
+// classes.  Use DiffSlices where possible.
+func DiffSlicesFunc[T any](a, b []T, eq func(x, y T) bool, opts ...Option) []Edit[T] {
+	var classes []T
+	return diffSlices(a, b, func(item T) (rune, bool) {
+		for i, x := range classes {
+			if eq(x, item) {
+				return lineRune(i + 1), true
+			}
+		}
+		if len(classes) >= maxLineIndex-3 {
+			return 0, false
+		}
+		classes = append(classes, item)
+		return lineRune(len(classes)), true
+	}, eq, opts)
+}
+
+// diffSlices reduces a and b to runes using class, diffs them, and expands
+// the diffs back to edits.  When class runs out of runes, the rest of each
+// slice is reduced to a single rune, which is the same for both slices if
+// their rests are equal according to eq.  A timeout would leave the edits
+// too coarse to be of use, so there is none unless opts set one.
+func diffSlices[T any](a, b []T, class func(T) (rune, bool), eq func(x, y T) bool, opts []Option) []Edit[T] {
+	runes1, rest1 := sliceToRunes(a, class)
+	runes2, rest2 := sliceToRunes(b, class)
+	if rest1 != len(a) {
+		runes1 = append(runes1, sliceRest1)
+	}
+	if rest2 != len(b) {
+		runes2 = append(runes2, sliceRest2)
+		if rest1 != len(a) && slicesEqual(a[rest1:], b[rest2:], eq) {
+			runes2[len(runes2)-1] = sliceRest1
+		}
+	}
+	diffs := configWith(append([]Option{WithTimeout(0)}, opts...)).DiffRunes(runes1, runes2, false)
+	edits := make([]Edit[T], 0, len(diffs))
+	i, j := 0, 0
+	for _, d := range diffs {
+		runes := []rune(d.Text)
+		if len(runes) == 0 {
+			continue
+		}
+		n1, n2 := len(runes), len(runes)
+		switch runes[len(runes)-1] {
+		case sliceRest1:
+			n1, n2 = n1-1+len(a)-rest1, n2-1+len(b)-rest2
+		case sliceRest2:
+			n2 += len(b) - rest2 - 1
+		}
+		switch d.Op {
+		case OpDelete:
+			edits = append(edits, Edit[T]{OpDelete, a[i : i+n1]})
+			i += n1
+		case OpInsert:
+			edits = append(edits, Edit[T]{OpInsert, b[j : j+n2]})
+			j += n2
+		case OpEqual:
+			edits = append(edits, Edit[T]{OpEqual, a[i : i+n1]})
+			i += n1
+			j += n2
+		}
+	}
+	return edits
+}
+
+// Runes standing for the rest of each slice when class runs out of runes.
+var (
+	sliceRest1 = lineRune(maxLineIndex - 2)
+	sliceRest2 = lineRune(maxLineIndex - 1)
+)
+
+// sliceToRunes reduces items to runes using class.  Returns the runes, and
+// the index of the first item that could not be reduced, or len(items).
+func sliceToRunes[T any](items []T, class func(T) (rune, bool)) ([]rune, int) {
+	runes := make([]rune, 0, len(items))
+	for i, item := range items {
+		r, ok := class(item)
+		if !ok {
+			return runes, i
+		}
+		runes = append(runes, r)
+	}
+	return runes, len(items)
+}
+
+// slicesEqual reports whether a and b hold equal items.
+func slicesEqual[T any](a, b []T, eq func(x, y T) bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !eq(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
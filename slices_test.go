@@ -0,0 +1,115 @@
+package diffmatchpatch
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffSlices(t *testing.T) {
+	tests := []struct {
+		Name     string
+		A        []string
+		B        []string
+		Expected []Edit[string]
+	}{
+		{"Empty", nil, nil, []Edit[string]{}},
+		{"Insert into empty", nil, []string{"a", "b"}, []Edit[string]{{OpInsert, []string{"a", "b"}}}},
+		{"Delete all", []string{"a", "b"}, nil, []Edit[string]{{OpDelete, []string{"a", "b"}}}},
+		{
+			"Equal",
+			[]string{"a", "b", "c"},
+			[]string{"a", "b", "c"},
+			[]Edit[string]{{OpEqual, []string{"a", "b", "c"}}},
+		},
+		{
+			"Changed item",
+			[]string{"alpha", "beta", "gamma"},
+			[]string{"alpha", "BETA", "gamma"},
+			[]Edit[string]{
+				{OpEqual, []string{"alpha"}},
+				{OpDelete, []string{"beta"}},
+				{OpInsert, []string{"BETA"}},
+				{OpEqual, []string{"gamma"}},
+			},
+		},
+		{
+			"Insert and delete",
+			[]string{"a", "b", "c", "d"},
+			[]string{"x", "a", "c", "d", "y"},
+			[]Edit[string]{
+				{OpInsert, []string{"x"}},
+				{OpEqual, []string{"a"}},
+				{OpDelete, []string{"b"}},
+				{OpEqual, []string{"c", "d"}},
+				{OpInsert, []string{"y"}},
+			},
+		},
+	}
+	for i, test := range tests {
+		actual := DiffSlices(test.A, test.B)
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+
+	type record struct {
+		ID   int
+		Name string
+	}
+	a := []record{{1, "one"}, {2, "two"}, {3, "three"}}
+	b := []record{{1, "one"}, {3, "three"}, {4, "four"}}
+	assert.Equal(t, []Edit[record]{
+		{OpEqual, []record{{1, "one"}}},
+		{OpDelete, []record{{2, "two"}}},
+		{OpEqual, []record{{3, "three"}}},
+		{OpInsert, []record{{4, "four"}}},
+	}, DiffSlices(a, b))
+
+	// There is no timeout unless one is given.
+	x := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	y := []int{1, 9, 3, 4, 10, 6, 11, 8}
+	assert.Equal(t, []Edit[int]{
+		{OpEqual, []int{1}},
+		{OpDelete, []int{2}},
+		{OpInsert, []int{9}},
+		{OpEqual, []int{3, 4}},
+		{OpDelete, []int{5}},
+		{OpInsert, []int{10}},
+		{OpEqual, []int{6}},
+		{OpDelete, []int{7}},
+		{OpInsert, []int{11}},
+		{OpEqual, []int{8}},
+	}, DiffSlices(x, y))
+	assert.Equal(t, []Edit[int]{
+		{OpEqual, []int{1}},
+		{OpDelete, []int{2, 3, 4, 5, 6, 7}},
+		{OpInsert, []int{9, 3, 4, 10, 6, 11}},
+		{OpEqual, []int{8}},
+	}, DiffSlices(x, y, WithTimeout(time.Nanosecond)))
+}
+
+func TestDiffSlicesFunc(t *testing.T) {
+	a := []string{"Alpha", "beta", "Gamma", "delta"}
+	b := []string{"alpha", "BETA", "epsilon", "gamma", "DELTA"}
+	actual := DiffSlicesFunc(a, b, strings.EqualFold)
+	assert.Equal(t, []Edit[string]{
+		{OpEqual, []string{"Alpha", "beta"}},
+		{OpInsert, []string{"epsilon"}},
+		{OpEqual, []string{"Gamma", "delta"}},
+	}, actual)
+
+	// Slices of uncomparable items.
+	x := [][]int{{1}, {2, 3}, {4}}
+	y := [][]int{{1}, {4}, {5, 6}}
+	eq := func(p, q []int) bool {
+		return fmt.Sprint(p) == fmt.Sprint(q)
+	}
+	assert.Equal(t, []Edit[[]int]{
+		{OpEqual, [][]int{{1}}},
+		{OpDelete, [][]int{{2, 3}}},
+		{OpEqual, [][]int{{4}}},
+		{OpInsert, [][]int{{5, 6}}},
+	}, DiffSlicesFunc(x, y, eq))
+}
@@ -0,0 +1,169 @@
+// Package sync implements differential synchronization of a text between
+// peers, as described by Neil Fraser in "Differential Synchronization"
+// (https://neil.fraser.name/writing/sync/).
+//
+// Each peer keeps a Doc holding its copy of the text, and a Session for each
+// peer it syncs with.  A session keeps a shadow of the text as last agreed
+// with the other peer, a backup of the shadow, and a stack of edits not yet
+// acknowledged.  Edits are found by diffing the shadow against the text, sent
+// as deltas against the shadow, and applied to the other peer's text as
+// fuzzy patches, so both peers can edit at the same time.
+//
+// Sessions take turns: one peer sends, the other receives and then sends
+// back.  Messages may be lost, in which case the edit stack is sent again
+// and the backup shadow is used to recover.
+package sync
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kenshaw/diffmatchpatch"
+)
+
+// ErrOutOfSync is returned by Session.Receive when a message can not be
+// applied to the shadow.  The session must be recreated from a common text.
+var ErrOutOfSync = errors.New("session out of sync")
+
+// Doc is a text shared by any number of sessions.
+type Doc struct {
+	Text string
+}
+
+// NewDoc creates a new document holding text.
+func NewDoc(text string) *Doc {
+	return &Doc{Text: text}
+}
+
+// Edit is a set of changes to a shadow.
+type Edit struct {
+	// Version is the number of edits made by the sender before this one.
+	Version int
+	// Delta is the changes to the sender's shadow at Version, as encoded by
+	// DiffToDelta.
+	Delta string
+}
+
+// Message is sent from a session to the other peer's session.
+type Message struct {
+	// Ack is the number of edits received from the other peer.
+	Ack int
+	// Edits are the edits the other peer has not acknowledged.
+	Edits []Edit
+}
+
+// shadow is a copy of the text as last agreed with the other peer.
+type shadow struct {
+	text string
+	// Number of edits made by this peer, and received from the other peer.
+	local, remote int
+}
+
+// Session syncs a document with a session of another peer.  Sessions are
+// not safe for concurrent use.
+type Session struct {
+	config *diffmatchpatch.Config
+	doc    *Doc
+	shadow shadow
+	backup shadow
+	edits  []Edit
+}
+
+// NewSession creates a session syncing doc.  The session of the other peer
+// must start from the same text.
+func NewSession(config *diffmatchpatch.Config, doc *Doc) *Session {
+	return &Session{
+		config: config,
+		doc:    doc,
+		shadow: shadow{text: doc.Text},
+		backup: shadow{text: doc.Text},
+	}
+}
+
+// Doc returns the document of the session.
+func (s *Session) Doc() *Doc {
+	return s.doc
+}
+
+// Send diffs the document against the shadow, and returns a message with
+// the changes for the other peer.
+func (s *Session) Send() Message {
+	if s.doc.Text != s.shadow.text {
+		diffs := s.config.Diff(s.shadow.text, s.doc.Text, true)
+		diffs = s.config.DiffCleanupEfficiency(diffs)
+		s.edits = append(s.edits, Edit{
+			Version: s.shadow.local,
+			Delta:   s.config.DiffToDelta(diffs),
+		})
+		s.shadow.text = s.doc.Text
+		s.shadow.local++
+	}
+	edits := make([]Edit, len(s.edits))
+	copy(edits, s.edits)
+	return Message{Ack: s.shadow.remote, Edits: edits}
+}
+
+// Receive applies a message from the other peer to the shadow, and patches
+// the changes into the document.
+func (s *Session) Receive(m Message) error {
+	if m.Ack != s.shadow.local {
+		if err := s.restore(m.Ack); err != nil {
+			return err
+		}
+	}
+	// Drop the acknowledged edits.
+	n := 0
+	for n < len(s.edits) && s.edits[n].Version < m.Ack {
+		n++
+	}
+	s.edits = s.edits[n:]
+	for _, e := range m.Edits {
+		switch {
+		case e.Version < s.shadow.remote:
+			// Already received.
+			continue
+		case e.Version > s.shadow.remote:
+			return fmt.Errorf("%w: edit %d received after %d edits", ErrOutOfSync, e.Version, s.shadow.remote)
+		}
+		diffs, err := s.config.DiffFromDelta(s.shadow.text, e.Delta)
+		if err != nil {
+			return fmt.Errorf("%w: edit %d: %v", ErrOutOfSync, e.Version, err)
+		}
+		patches, err := s.config.PatchMakeFromTextAndDiffs(s.shadow.text, diffs)
+		if err != nil {
+			return fmt.Errorf("%w: edit %d: %v", ErrOutOfSync, e.Version, err)
+		}
+		s.doc.Text, _ = s.config.PatchApply(patches, s.doc.Text)
+		s.shadow.text = s.config.DiffText2(diffs)
+		s.shadow.remote++
+	}
+	s.backup = s.shadow
+	return nil
+}
+
+// restore rolls the shadow back to the version acknowledged by the other
+// peer, replaying the edits made since the backup.  The edits from that
+// version on were lost: their changes are still in the document, and are
+// found again by the next Send.
+func (s *Session) restore(version int) error {
+	if version < s.backup.local || version > s.shadow.local {
+		return fmt.Errorf("%w: acknowledged %d edits of %d", ErrOutOfSync, version, s.shadow.local)
+	}
+	shadow := s.backup
+	for _, e := range s.edits {
+		if e.Version < shadow.local {
+			continue
+		}
+		if e.Version >= version {
+			break
+		}
+		diffs, err := s.config.DiffFromDelta(shadow.text, e.Delta)
+		if err != nil {
+			return fmt.Errorf("%w: edit %d: %v", ErrOutOfSync, e.Version, err)
+		}
+		shadow.text = s.config.DiffText2(diffs)
+		shadow.local++
+	}
+	s.shadow, s.edits = shadow, nil
+	return nil
+}
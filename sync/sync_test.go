@@ -0,0 +1,156 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/kenshaw/diffmatchpatch"
+	"github.com/stretchr/testify/assert"
+)
+
+// round syncs client with server over up and down: the client pushes its
+// changes, and the server pulls them and pushes its own back.
+func round(t *testing.T, client, server *Session, up, down *Pipe) {
+	t.Helper()
+	assert.NoError(t, client.Push(up))
+	assert.NoError(t, server.Pull(up))
+	assert.NoError(t, server.Push(down))
+	assert.NoError(t, client.Pull(down))
+}
+
+func TestSession(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Text     string
+		Client   string
+		Server   string
+		Expected string
+	}{
+		{"No changes", "The cat", "The cat", "The cat", "The cat"},
+		{"Client change", "The cat", "The big cat", "The cat", "The big cat"},
+		{"Server change", "The cat", "The cat", "The cat sat", "The cat sat"},
+		{"Both change", "The cat", "The big cat", "The cat sat", "The big cat sat"},
+		{
+			"Both change lines",
+			"one\ntwo\nthree\nfour\nfive\n",
+			"one\n2\nthree\nfour\nfive\n",
+			"one\ntwo\nthree\nfour\n5\n",
+			"one\n2\nthree\nfour\n5\n",
+		},
+	}
+	config := diffmatchpatch.NewDefaultConfig()
+	for i, test := range tests {
+		clientDoc, serverDoc := NewDoc(test.Text), NewDoc(test.Text)
+		client, server := NewSession(config, clientDoc), NewSession(config, serverDoc)
+		clientDoc.Text, serverDoc.Text = test.Client, test.Server
+		up, down := new(Pipe), new(Pipe)
+		round(t, client, server, up, down)
+		assert.Equal(t, test.Expected, clientDoc.Text, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		assert.Equal(t, test.Expected, serverDoc.Text, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		// The edits have been acknowledged.
+		msg := client.Send()
+		assert.Empty(t, msg.Edits, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		assert.NoError(t, server.Receive(msg), fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		assert.Empty(t, server.Send().Edits, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
+
+func TestSessionLostMessages(t *testing.T) {
+	config := diffmatchpatch.NewDefaultConfig()
+	clientDoc, serverDoc := NewDoc("The cat"), NewDoc("The cat")
+	client, server := NewSession(config, clientDoc), NewSession(config, serverDoc)
+	drop := true
+	up := &Pipe{Drop: func(Message) bool { return drop }}
+	down := &Pipe{Drop: func(Message) bool { return drop }}
+
+	// The client's changes are lost on the way up.
+	clientDoc.Text = "The big cat"
+	round(t, client, server, up, down)
+	assert.Equal(t, "The cat", serverDoc.Text)
+	// The server's changes are lost on the way down.
+	drop = false
+	clientDoc.Text = "The big black cat"
+	serverDoc.Text = "The cat sat"
+	assert.NoError(t, client.Push(up))
+	assert.NoError(t, server.Pull(up))
+	assert.Equal(t, "The big black cat sat", serverDoc.Text)
+	drop = true
+	assert.NoError(t, server.Push(down))
+	assert.Equal(t, 0, down.Len())
+	// The client sends its edits again, and the server restores its backup
+	// shadow.
+	drop = false
+	clientDoc.Text = "A big black cat"
+	round(t, client, server, up, down)
+	assert.Equal(t, "A big black cat sat", serverDoc.Text)
+	assert.Equal(t, "A big black cat sat", clientDoc.Text)
+	assert.Equal(t, 0, up.Len())
+	assert.Equal(t, 0, down.Len())
+}
+
+func TestSessionMultipleClients(t *testing.T) {
+	config := diffmatchpatch.NewDefaultConfig()
+	serverDoc := NewDoc("alpha beta gamma")
+	doc1, doc2 := NewDoc(serverDoc.Text), NewDoc(serverDoc.Text)
+	client1, client2 := NewSession(config, doc1), NewSession(config, doc2)
+	server1, server2 := NewSession(config, serverDoc), NewSession(config, serverDoc)
+	up1, down1, up2, down2 := new(Pipe), new(Pipe), new(Pipe), new(Pipe)
+	doc1.Text = "ALPHA beta gamma"
+	doc2.Text = "alpha beta GAMMA"
+	round(t, client1, server1, up1, down1)
+	round(t, client2, server2, up2, down2)
+	round(t, client1, server1, up1, down1)
+	assert.Equal(t, "ALPHA beta GAMMA", serverDoc.Text)
+	assert.Equal(t, "ALPHA beta GAMMA", doc1.Text)
+	assert.Equal(t, "ALPHA beta GAMMA", doc2.Text)
+}
+
+func TestSessionOutOfSync(t *testing.T) {
+	config := diffmatchpatch.NewDefaultConfig()
+	client := NewSession(config, NewDoc("abc"))
+	server := NewSession(config, NewDoc("wxyz"))
+	client.Doc().Text = "abcd"
+	err := server.Receive(client.Send())
+	assert.True(t, errors.Is(err, ErrOutOfSync), fmt.Sprintf("%v", err))
+	// Edits skipped.
+	err = server.Receive(Message{Edits: []Edit{{Version: 1, Delta: "=3"}}})
+	assert.True(t, errors.Is(err, ErrOutOfSync), fmt.Sprintf("%v", err))
+	// Unknown acknowledgement.
+	err = server.Receive(Message{Ack: 5})
+	assert.True(t, errors.Is(err, ErrOutOfSync), fmt.Sprintf("%v", err))
+}
+
+func TestSessionConverges(t *testing.T) {
+	config := diffmatchpatch.NewDefaultConfig()
+	r := rand.New(rand.NewSource(1))
+	edit := func(text string) string {
+		words := []string{"red ", "green ", "blue ", "x", "\n"}
+		i := r.Intn(len(text) + 1)
+		j := i + r.Intn(4)
+		if j > len(text) {
+			j = len(text)
+		}
+		return text[:i] + words[r.Intn(len(words))] + text[j:]
+	}
+	for n := 0; n < 20; n++ {
+		clientDoc, serverDoc := NewDoc("the quick brown fox"), NewDoc("the quick brown fox")
+		client, server := NewSession(config, clientDoc), NewSession(config, serverDoc)
+		lossy := func(Message) bool { return r.Intn(3) == 0 }
+		up, down := &Pipe{Drop: lossy}, &Pipe{Drop: lossy}
+		for i := 0; i < 30; i++ {
+			if r.Intn(2) == 0 {
+				clientDoc.Text = edit(clientDoc.Text)
+			}
+			if r.Intn(2) == 0 {
+				serverDoc.Text = edit(serverDoc.Text)
+			}
+			round(t, client, server, up, down)
+		}
+		up.Drop, down.Drop = nil, nil
+		round(t, client, server, up, down)
+		round(t, client, server, up, down)
+		assert.Equal(t, serverDoc.Text, clientDoc.Text, fmt.Sprintf("Test case #%d", n))
+	}
+}
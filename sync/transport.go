@@ -0,0 +1,69 @@
+package sync
+
+import "errors"
+
+// ErrNoMessage is returned by Transport.Receive when there is no message
+// waiting.
+var ErrNoMessage = errors.New("no message")
+
+// Transport carries messages one way between sessions.
+type Transport interface {
+	// Send sends a message.
+	Send(Message) error
+	// Receive returns the next message, or ErrNoMessage.
+	Receive() (Message, error)
+}
+
+// Pipe is an in-memory transport, delivering messages in the order they
+// were sent.  A Pipe is not safe for concurrent use.
+type Pipe struct {
+	// Drop, if set, drops messages for which it returns true, to simulate
+	// an unreliable network.
+	Drop  func(Message) bool
+	queue []Message
+}
+
+// Send queues m, unless it is dropped.
+func (p *Pipe) Send(m Message) error {
+	if p.Drop != nil && p.Drop(m) {
+		return nil
+	}
+	p.queue = append(p.queue, m)
+	return nil
+}
+
+// Receive returns the oldest queued message, or ErrNoMessage.
+func (p *Pipe) Receive() (Message, error) {
+	if len(p.queue) == 0 {
+		return Message{}, ErrNoMessage
+	}
+	m := p.queue[0]
+	p.queue = p.queue[1:]
+	return m, nil
+}
+
+// Len returns the number of queued messages.
+func (p *Pipe) Len() int {
+	return len(p.queue)
+}
+
+// Push sends the changes to the document over t.
+func (s *Session) Push(t Transport) error {
+	return t.Send(s.Send())
+}
+
+// Pull receives and applies all messages waiting on t.
+func (s *Session) Pull(t Transport) error {
+	for {
+		m, err := t.Receive()
+		switch {
+		case err == ErrNoMessage:
+			return nil
+		case err != nil:
+			return err
+		}
+		if err := s.Receive(m); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package diffmatchpatch
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TermColor is a color mode of the terminal renderers.
+type TermColor int
+
+// Color modes.
+const (
+	// TermColorNone writes no escape codes, for output that is piped or
+	// written to a file.
+	TermColorNone TermColor = iota
+	// TermColor16 uses the basic ANSI foreground colors, and reverse video
+	// for changes within lines.
+	TermColor16
+	// TermColor256 uses backgrounds from the 256 color palette.
+	TermColor256
+)
+
+// TermOptions are the options of the terminal renderers.
+type TermOptions struct {
+	// Color is the color mode.
+	Color TermColor
+	// Width is the width of the terminal in columns, used by side-by-side
+	// diffs.  Defaults to 80.
+	Width int
+	// TabWidth is the distance between tab stops.  Defaults to 8.
+	TabWidth int
+}
+
+// termReset resets the terminal colors.
+const termReset = "\x1b[0m"
+
+// DiffTermUnified converts a []Diff into a unified diff for display in a
+// terminal, with "-" and "+" gutters, and the changes within changed lines
+// highlighted when colored.  Unchanged lines further than UnifiedContext
+// lines from a change are left out, and each hunk starts with a unified
+// diff hunk header.
+//
+// An empty string is returned when the texts are equal.
+func (config *Config) DiffTermUnified(diffs []Diff, opts TermOptions) string {
+	var buf bytes.Buffer
+	// The number of lines of each text before the current block.
+	n1, n2 := 0, 0
+	for _, block := range foldRows(config.sideBySideRows(diffs), config.UnifiedContext) {
+		len1, len2 := 0, 0
+		for _, row := range block.Rows {
+			if row.Left.Num != 0 {
+				len1++
+			}
+			if row.Right.Num != 0 {
+				len2++
+			}
+		}
+		if !block.Folded {
+			_, _ = buf.WriteString(opts.meta("@@ -"+unifiedCoords(n1, len1)+" +"+unifiedCoords(n2, len2)+" @@") + "\n")
+			for i := 0; i < len(block.Rows); {
+				if !block.Rows[i].Changed {
+					_, _ = buf.WriteString(opts.unifiedLine(" ", block.Rows[i].Left.Diffs, OpEqual))
+					i++
+					continue
+				}
+				// Write the deleted lines of a run of changed rows, then
+				// the inserted lines.
+				j := i
+				for j < len(block.Rows) && block.Rows[j].Changed {
+					j++
+				}
+				for _, row := range block.Rows[i:j] {
+					if row.Left.Num != 0 {
+						_, _ = buf.WriteString(opts.unifiedLine("-", row.Left.Diffs, OpDelete))
+					}
+				}
+				for _, row := range block.Rows[i:j] {
+					if row.Right.Num != 0 {
+						_, _ = buf.WriteString(opts.unifiedLine("+", row.Right.Diffs, OpInsert))
+					}
+				}
+				i = j
+			}
+		}
+		n1, n2 = n1+len1, n2+len2
+	}
+	return buf.String()
+}
+
+// DiffTermSideBySide converts a []Diff into a side-by-side diff for display
+// in a terminal of opts.Width columns, with the numbered lines of the source
+// text on the left and of the destination text on the right.  Long lines
+// are wrapped.  The column between the sides marks changed lines with "|",
+// deleted lines with "<" and inserted lines with ">".  Unchanged lines
+// further than UnifiedContext lines from a change are folded into a single
+// line saying how many lines are folded.
+func (config *Config) DiffTermSideBySide(diffs []Diff, opts TermOptions) string {
+	rows := config.sideBySideRows(diffs)
+	width := opts.Width
+	if width <= 0 {
+		width = 80
+	}
+	// The widths of the line numbers, and of the text of each side.
+	num1, num2 := 0, 0
+	for _, row := range rows {
+		num1, num2 = max(num1, row.Left.Num), max(num2, row.Right.Num)
+	}
+	numWidth := len(strconv.Itoa(max(num1, num2)))
+	// Each side is at least as wide as the widest rune.
+	textWidth := max(2, (width-2*numWidth-5)/2)
+	var buf bytes.Buffer
+	for _, block := range foldRows(rows, config.UnifiedContext) {
+		if block.Folded {
+			text := foldedLines(len(block.Rows))
+			_, _ = buf.WriteString(strings.Repeat(" ", max(0, (width-len(text))/2)) + opts.meta(text) + "\n")
+			continue
+		}
+		for _, row := range block.Rows {
+			op1, op2, mark := OpEqual, OpEqual, " "
+			if row.Changed {
+				op1, op2 = OpDelete, OpInsert
+				switch {
+				case row.Left.Num == 0:
+					mark = ">"
+				case row.Right.Num == 0:
+					mark = "<"
+				default:
+					mark = "|"
+				}
+			}
+			left, right := opts.side(row.Left, op1, textWidth), opts.side(row.Right, op2, textWidth)
+			for i := 0; i < len(left) || i < len(right); i++ {
+				var line string
+				if i == 0 {
+					line = termNum(row.Left.Num, numWidth) + " " + termCell(left, i, textWidth) + " " + mark + " " +
+						termNum(row.Right.Num, numWidth) + " " + termCell(right, i, textWidth)
+				} else {
+					line = strings.Repeat(" ", numWidth+1) + termCell(left, i, textWidth) + "   " +
+						strings.Repeat(" ", numWidth+1) + termCell(right, i, textWidth)
+				}
+				if opts.Color == TermColorNone {
+					line = strings.TrimRight(line, " ")
+				}
+				_, _ = buf.WriteString(line + "\n")
+			}
+		}
+	}
+	return buf.String()
+}
+
+// unifiedLine renders a line of op of a unified diff, with its gutter.
+func (opts TermOptions) unifiedLine(gutter string, diffs []Diff, op Op) string {
+	if style, _ := opts.style(op); style != "" {
+		gutter = style + gutter + termReset
+	}
+	return gutter + opts.wrap(diffs, op, 0)[0] + "\n"
+}
+
+// side wraps one side of a side-by-side row, returning no lines when the
+// side is empty.
+func (opts TermOptions) side(line sideLine, op Op, width int) []string {
+	if line.Num == 0 {
+		return nil
+	}
+	return opts.wrap(line.Diffs, op, width)
+}
+
+// termNum formats a line number right aligned in width columns, or blanks
+// for 0.
+func termNum(num, width int) string {
+	if num == 0 {
+		return strings.Repeat(" ", width)
+	}
+	s := strconv.Itoa(num)
+	return strings.Repeat(" ", width-len(s)) + s
+}
+
+// termCell returns line i of a wrapped side, or blanks of the given width.
+func termCell(lines []string, i, width int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return strings.Repeat(" ", width)
+}
+
+// style returns the escape codes starting a line of op, and the changes
+// within it.
+func (opts TermOptions) style(op Op) (string, string) {
+	switch {
+	case opts.Color == TermColor16 && op == OpDelete:
+		return "\x1b[31m", "\x1b[7;31m"
+	case opts.Color == TermColor16 && op == OpInsert:
+		return "\x1b[32m", "\x1b[7;32m"
+	case opts.Color == TermColor256 && op == OpDelete:
+		return "\x1b[48;5;52m", "\x1b[48;5;124m"
+	case opts.Color == TermColor256 && op == OpInsert:
+		return "\x1b[48;5;22m", "\x1b[48;5;28m"
+	}
+	return "", ""
+}
+
+// meta colors text that is not part of either text, such as hunk headers.
+func (opts TermOptions) meta(text string) string {
+	switch opts.Color {
+	case TermColor16:
+		return "\x1b[36m" + text + termReset
+	case TermColor256:
+		return "\x1b[38;5;75m" + text + termReset
+	}
+	return text
+}
+
+// wrap renders the text of diffs as lines of op, highlighting the changes
+// within it, expanding tabs and showing control characters in caret
+// notation.  When width is positive, the text is wrapped at width columns,
+// and each line padded to width columns.  A width of 1 is too narrow for
+// wide runes and caret notation, which then overflow the line.
+func (opts TermOptions) wrap(diffs []Diff, op Op, width int) []string {
+	lineStyle, changeStyle := opts.style(op)
+	tabWidth := opts.TabWidth
+	if tabWidth <= 0 {
+		tabWidth = 8
+	}
+	var lines []string
+	var buf bytes.Buffer
+	// The column in the text, for tab stops, and in the current line.
+	col, n := 0, 0
+	// The style written to buf, and the style of the next text.
+	cur, next := "", lineStyle
+	write := func(s string) {
+		if cur != next {
+			if cur != "" {
+				_, _ = buf.WriteString(termReset)
+			}
+			_, _ = buf.WriteString(next)
+			cur = next
+		}
+		_, _ = buf.WriteString(s)
+	}
+	flush := func() {
+		if width > n {
+			// Pad in the style of the line, not of a change.
+			style := next
+			next = lineStyle
+			write(strings.Repeat(" ", width-n))
+			next = style
+		}
+		if cur != "" {
+			_, _ = buf.WriteString(termReset)
+		}
+		lines = append(lines, buf.String())
+		buf.Reset()
+		cur, n = "", 0
+	}
+	for _, d := range diffs {
+		next = lineStyle
+		if d.Op != OpEqual {
+			next = changeStyle
+		}
+		for _, r := range d.Text {
+			var s string
+			var w int
+			switch {
+			case r == '\t':
+				w = tabWidth - col%tabWidth
+				if width > 0 {
+					w = min(w, width)
+				}
+				s = strings.Repeat(" ", w)
+			case r < 0x20:
+				s, w = "^"+string(r+'@'), 2
+			case r == 0x7f:
+				s, w = "^?", 2
+			default:
+				s, w = string(r), runeWidth(r)
+			}
+			if width > 0 && n != 0 && n+w > width {
+				flush()
+			}
+			write(s)
+			col, n = col+w, n+w
+		}
+	}
+	flush()
+	return lines
+}
+
+// wideRunes are the ranges of East Asian wide and full width runes.
+var wideRunes = [][2]rune{
+	{0x1100, 0x115f},
+	{0x231a, 0x231b},
+	{0x2329, 0x232a},
+	{0x23e9, 0x23ec},
+	{0x25fd, 0x25fe},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x26aa, 0x26ab},
+	{0x26bd, 0x26be},
+	{0x26c4, 0x26c5},
+	{0x2705, 0x2705},
+	{0x270a, 0x270b},
+	{0x2753, 0x2755},
+	{0x2795, 0x2797},
+	{0x2b1b, 0x2b1c},
+	{0x2e80, 0x303e},
+	{0x3041, 0x33ff},
+	{0x3400, 0x4dbf},
+	{0x4e00, 0x9fff},
+	{0xa000, 0xa4cf},
+	{0xa960, 0xa97f},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x16fe0, 0x18d08},
+	{0x1b000, 0x1b2ff},
+	{0x1f004, 0x1f004},
+	{0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e},
+	{0x1f191, 0x1f19a},
+	{0x1f200, 0x1f251},
+	{0x1f300, 0x1f64f},
+	{0x1f680, 0x1f6ff},
+	{0x1f7e0, 0x1f7eb},
+	{0x1f90c, 0x1f9ff},
+	{0x1fa70, 0x1faff},
+	{0x20000, 0x2fffd},
+	{0x30000, 0x3fffd},
+}
+
+// runeWidth returns the number of terminal columns taken by r: 0 for
+// combining marks and other zero width runes, 2 for East Asian wide and full
+// width runes, and 1 otherwise.
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	// Binary search the ranges.
+	i, j := 0, len(wideRunes)
+	for i < j {
+		h := (i + j) / 2
+		switch {
+		case r < wideRunes[h][0]:
+			j = h
+		case r > wideRunes[h][1]:
+			i = h + 1
+		default:
+			return 2
+		}
+	}
+	return 1
+}
@@ -0,0 +1,146 @@
+package diffmatchpatch
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuneWidth(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Rune     rune
+		Expected int
+	}{
+		{"ASCII", 'a', 1},
+		{"Latin", 'é', 1},
+		{"Combining mark", '\u0301', 0},
+		{"Zero width space", '\u200b', 0},
+		{"Hangul jamo", 'ᄀ', 2},
+		{"Ideograph", '日', 2},
+		{"Hiragana", 'の', 2},
+		{"Hangul syllable", '한', 2},
+		{"Full width", 'Ａ', 2},
+		{"Half width katakana", 'ｱ', 1},
+		{"Emoji", '😀', 2},
+		{"Box drawing", '│', 1},
+	}
+	for i, test := range tests {
+		actual := runeWidth(test.Rune)
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
+
+func TestTermWrap(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Options  TermOptions
+		Diffs    []Diff
+		Op       Op
+		Width    int
+		Expected []string
+	}{
+		{"Empty", TermOptions{}, nil, OpEqual, 0, []string{""}},
+		{"Empty padded", TermOptions{}, nil, OpEqual, 3, []string{"   "}},
+		{"Tabs", TermOptions{}, []Diff{{OpEqual, "a\tbc\td"}}, OpEqual, 0, []string{"a       bc      d"}},
+		{"Tab width", TermOptions{TabWidth: 4}, []Diff{{OpEqual, "a\tb"}}, OpEqual, 0, []string{"a   b"}},
+		{"Control characters", TermOptions{}, []Diff{{OpEqual, "a\x01b\r\x7f"}}, OpEqual, 0, []string{"a^Ab^M^?"}},
+		{
+			"Wrapped",
+			TermOptions{TabWidth: 4},
+			[]Diff{{OpEqual, "a\tb\x01日本é"}},
+			OpEqual,
+			5,
+			[]string{"a   b", "^A日 ", "本é  "},
+		},
+		{
+			"Highlighted",
+			TermOptions{Color: TermColor16},
+			[]Diff{{OpEqual, "ab"}, {OpDelete, "cd"}},
+			OpDelete,
+			0,
+			[]string{"\x1b[31mab\x1b[0m\x1b[7;31mcd\x1b[0m"},
+		},
+		{
+			"Highlighted and wrapped",
+			TermOptions{Color: TermColor256},
+			[]Diff{{OpEqual, "ab"}, {OpInsert, "cdef"}, {OpEqual, "g"}},
+			OpInsert,
+			3,
+			[]string{
+				"\x1b[48;5;22mab\x1b[0m\x1b[48;5;28mc\x1b[0m",
+				"\x1b[48;5;28mdef\x1b[0m",
+				"\x1b[48;5;22mg  \x1b[0m",
+			},
+		},
+		{"Empty highlighted", TermOptions{Color: TermColor256}, nil, OpDelete, 3, []string{"\x1b[48;5;52m   \x1b[0m"}},
+		{"Equal uncolored", TermOptions{Color: TermColor16}, []Diff{{OpEqual, "ab"}}, OpEqual, 3, []string{"ab "}},
+	}
+	for i, test := range tests {
+		actual := test.Options.wrap(test.Diffs, test.Op, test.Width)
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
+
+func TestDiffTerm(t *testing.T) {
+	diffs := []Diff{
+		{OpEqual, "a\nb\nc\n"},
+		{OpDelete, "f\tx <x>\n"},
+		{OpInsert, "F\tx <y> 日本語のテキスト\nnew\n"},
+		{OpEqual, "g\n"},
+	}
+	tests := []struct {
+		Name       string
+		Color      TermColor
+		Unified    string
+		SideBySide string
+	}{
+		{
+			"No color",
+			TermColorNone,
+			"@@ -3,3 +3,4 @@\n" +
+				" c\n" +
+				"-f       x <x>\n" +
+				"+F       x <y> 日本語のテキスト\n" +
+				"+new\n" +
+				" g\n",
+			"           2 unchanged lines\n" +
+				"3 c                  3 c\n" +
+				"4 f       x <x>    | 4 F       x <y> 日\n" +
+				"                       本語のテキスト\n" +
+				"                   > 5 new\n" +
+				"5 g                  6 g\n",
+		},
+		{
+			"16 colors",
+			TermColor16,
+			"\x1b[36m@@ -3,3 +3,4 @@\x1b[0m\n" +
+				" c\n" +
+				"\x1b[31m-\x1b[0m\x1b[7;31mf\x1b[0m\x1b[31m       x <\x1b[0m\x1b[7;31mx>\x1b[0m\n" +
+				"\x1b[32m+\x1b[0m\x1b[7;32mF\x1b[0m\x1b[32m       x <\x1b[0m\x1b[7;32my> 日本語のテキスト\x1b[0m\n" +
+				"\x1b[32m+\x1b[0m\x1b[32mnew\x1b[0m\n" +
+				" g\n",
+			"           \x1b[36m2 unchanged lines\x1b[0m\n" +
+				"3 c                  3 c               \n" +
+				"4 \x1b[7;31mf\x1b[0m\x1b[31m       x <\x1b[0m\x1b[7;31mx>\x1b[0m\x1b[31m   \x1b[0m | 4 \x1b[7;32mF\x1b[0m\x1b[32m       x <\x1b[0m\x1b[7;32my> 日\x1b[0m\n" +
+				"                       \x1b[7;32m本語のテキスト\x1b[0m\x1b[32m  \x1b[0m\n" +
+				"                   > 5 \x1b[32mnew             \x1b[0m\n" +
+				"5 g                  6 g               \n",
+		},
+	}
+	config := NewDefaultConfig()
+	config.UnifiedContext = 1
+	for i, test := range tests {
+		opts := TermOptions{Color: test.Color, Width: 40}
+		assert.Equal(t, test.Unified, config.DiffTermUnified(diffs, opts), fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		assert.Equal(t, test.SideBySide, config.DiffTermSideBySide(diffs, opts), fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+	// Equal texts.
+	diffs = []Diff{{OpEqual, "a\nb\n"}}
+	assert.Equal(t, "", config.DiffTermUnified(diffs, TermOptions{}))
+	assert.Equal(t, "           2 unchanged lines\n", config.DiffTermSideBySide(diffs, TermOptions{Width: 40}))
+	// Too narrow a width still leaves room for wide runes.
+	diffs = []Diff{{OpDelete, "日本\n"}, {OpInsert, "ab\n"}}
+	assert.Equal(t, "1 日 | 1 ab\n  本\n", config.DiffTermSideBySide(diffs, TermOptions{Width: 1}))
+}
@@ -0,0 +1,393 @@
+package diffmatchpatch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DiffUnified computes the line differences between two texts and returns
+// them in the unified diff format understood by patch(1) and git apply.
+// name1 and name2 are written in the "---" and "+++" headers.
+//
+// An empty string is returned when the texts are equal.
+func (config *Config) DiffUnified(name1, name2, text1, text2 string) string {
+	runes1, runes2, lines := linesToRunes(text1, text2)
+	diffs := runesToLines(config.diffLines(context.Background(), runes1, runes2, config.diffDeadline()), lines)
+	return config.unified(name1, name2, diffs)
+}
+
+// DiffToUnified converts a []Diff into the unified diff format. name1 and
+// name2 are written in the "---" and "+++" headers.
+//
+// Diffs that do not fall on line boundaries (e.g. a character diff) are
+// re-diffed line by line first.
+func (config *Config) DiffToUnified(name1, name2 string, diffs []Diff) string {
+	return config.unified(name1, name2, diffs)
+}
+
+// PatchToUnified converts a list of patches made against text1 (e.g. by
+// PatchMake) into the unified diff format. name1 and name2 are written in the
+// "---" and "+++" headers.
+//
+// Returns an error if the patches do not apply exactly to text1.
+func (config *Config) PatchToUnified(name1, name2, text1 string, patches []Patch) (string, error) {
+	// Each patch's coordinates are relative to the text with the previous
+	// patches applied.
+	text2 := text1
+	for i, p := range patches {
+		end := p.Start2 + p.Length1
+		if p.Start2 < 0 || end > len(text2) || config.DiffText1(p.Diffs) != text2[p.Start2:end] {
+			return "", fmt.Errorf("patch %d does not apply to text at %d", i, p.Start2)
+		}
+		text2 = text2[:p.Start2] + config.DiffText2(p.Diffs) + text2[end:]
+	}
+	return config.DiffUnified(name1, name2, text1, text2), nil
+}
+
+// unifiedLine is a single line of a unified diff.
+type unifiedLine struct {
+	Op   Op
+	Text string
+}
+
+// unified writes diffs in the unified diff format.
+func (config *Config) unified(name1, name2 string, diffs []Diff) string {
+	var buf bytes.Buffer
+	_ = config.RenderHunks(&buf, &UnifiedRenderer{Name1: name1, Name2: name2}, diffs)
+	return buf.String()
+}
+
+// lineAligned returns diffs, re-diffed line by line if they do not fall on
+// line boundaries.
+func (config *Config) lineAligned(diffs []Diff) []Diff {
+	if diffLinesAligned(diffs) {
+		return diffs
+	}
+	runes1, runes2, lines := linesToRunes(config.DiffText1(diffs), config.DiffText2(diffs))
+	return runesToLines(config.diffLines(context.Background(), runes1, runes2, config.diffDeadline()), lines)
+}
+
+// unifiedCoords formats the 0-based start and length of a hunk range as
+// 1-based unified diff coordinates.
+func unifiedCoords(start, length int) string {
+	switch length {
+	case 0:
+		return strconv.Itoa(start) + ",0"
+	case 1:
+		return strconv.Itoa(start + 1)
+	}
+	return strconv.Itoa(start+1) + "," + strconv.Itoa(length)
+}
+
+// splitLines splits text after each newline.
+func splitLines(text string) []string {
+	var lines []string
+	for len(text) != 0 {
+		i := strings.IndexByte(text, '\n') + 1
+		if i == 0 {
+			i = len(text)
+		}
+		lines = append(lines, text[:i])
+		text = text[i:]
+	}
+	return lines
+}
+
+// diffLinesAligned returns whether every diff starts and ends on a line
+// boundary of the texts it belongs to.
+func diffLinesAligned(diffs []Diff) bool {
+	// Whether each text currently ends in a partial line.
+	var partial1, partial2 bool
+	for _, d := range diffs {
+		if len(d.Text) == 0 {
+			continue
+		}
+		if (d.Op != OpInsert && partial1) || (d.Op != OpDelete && partial2) {
+			return false
+		}
+		partial := !strings.HasSuffix(d.Text, "\n")
+		if d.Op != OpInsert {
+			partial1 = partial
+		}
+		if d.Op != OpDelete {
+			partial2 = partial
+		}
+	}
+	return true
+}
+
+// UnifiedFile holds the changes made to a single file by a unified diff.
+type UnifiedFile struct {
+	// OldName and NewName are the names of the file before and after the
+	// change, as written in the diff (e.g. "a/main.go" or "/dev/null").
+	OldName string
+	NewName string
+	// OldMode and NewMode are the git file modes (e.g. "100644"), if any.
+	OldMode string
+	NewMode string
+	// Rename and Copy report whether git recorded the change as a rename or
+	// copy of OldName.
+	Rename bool
+	Copy   bool
+	// Binary reports whether the file is a binary file with no textual
+	// hunks.
+	Binary bool
+	Hunks  []UnifiedHunk
+}
+
+// UnifiedHunk holds a single hunk of a unified diff.
+type UnifiedHunk struct {
+	// Start1 and Start2 are the 0-based line indexes of the hunk in the old
+	// and new file, and Length1 and Length2 are the line counts.
+	Start1  int
+	Start2  int
+	Length1 int
+	Length2 int
+	// Diffs are the lines of the hunk. Every line ends with a newline unless
+	// it was marked with "\ No newline at end of file".
+	Diffs []Diff
+}
+
+// UnifiedError is returned when a unified diff cannot be parsed.
+type UnifiedError struct {
+	// Line is the 1-based line number in the diff.
+	Line int
+	Msg  string
+}
+
+// Error satisfies the error interface.
+func (err *UnifiedError) Error() string {
+	return "unified diff line " + strconv.Itoa(err.Line) + ": " + err.Msg
+}
+
+var unifiedHunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// UnifiedFromText parses a unified diff, as produced by diff -u or git diff,
+// and returns the changes for each file in it.
+//
+// Lines outside of file headers and hunks (e.g. a commit message) are
+// ignored.
+func (config *Config) UnifiedFromText(text string) ([]UnifiedFile, error) {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	var files []UnifiedFile
+	// Whether the current file's "---" and "+++" headers have been seen.
+	var names bool
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSuffix(lines[i], "\n")
+		var file *UnifiedFile
+		if len(files) != 0 {
+			file = &files[len(files)-1]
+		}
+		// git extended headers are only valid before the first hunk.
+		extended := file != nil && !names && len(file.Hunks) == 0
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			oldName, newName := unifiedGitNames(line[len("diff --git "):])
+			files = append(files, UnifiedFile{OldName: oldName, NewName: newName})
+			names = false
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if file == nil || names || len(file.Hunks) != 0 {
+				files = append(files, UnifiedFile{})
+				file = &files[len(files)-1]
+			}
+			file.OldName = unifiedName(line[len("--- "):])
+			file.NewName = unifiedName(strings.TrimSuffix(lines[i+1], "\n")[len("+++ "):])
+			names = true
+			i++
+		case strings.HasPrefix(line, "@@ "):
+			if file == nil {
+				return nil, &UnifiedError{i + 1, "hunk outside of a file"}
+			}
+			hunk, next, err := parseUnifiedHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			file.Hunks = append(file.Hunks, hunk)
+			i = next - 1
+		case extended && strings.HasPrefix(line, "old mode "):
+			file.OldMode = line[len("old mode "):]
+		case extended && strings.HasPrefix(line, "new mode "):
+			file.NewMode = line[len("new mode "):]
+		case extended && strings.HasPrefix(line, "deleted file mode "):
+			file.OldMode = line[len("deleted file mode "):]
+			file.NewName = "/dev/null"
+		case extended && strings.HasPrefix(line, "new file mode "):
+			file.NewMode = line[len("new file mode "):]
+			file.OldName = "/dev/null"
+		case extended && strings.HasPrefix(line, "rename from "):
+			file.OldName = unifiedName(line[len("rename from "):])
+			file.Rename = true
+		case extended && strings.HasPrefix(line, "rename to "):
+			file.NewName = unifiedName(line[len("rename to "):])
+			file.Rename = true
+		case extended && strings.HasPrefix(line, "copy from "):
+			file.OldName = unifiedName(line[len("copy from "):])
+			file.Copy = true
+		case extended && strings.HasPrefix(line, "copy to "):
+			file.NewName = unifiedName(line[len("copy to "):])
+			file.Copy = true
+		case extended && (strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch"):
+			file.Binary = true
+		}
+	}
+	return files, nil
+}
+
+// parseUnifiedHunk parses the hunk starting at lines[i], returning the hunk
+// and the index of the line following it.
+func parseUnifiedHunk(lines []string, i int) (UnifiedHunk, int, error) {
+	m := unifiedHunkHeader.FindStringSubmatch(lines[i])
+	if m == nil {
+		return UnifiedHunk{}, 0, &UnifiedError{i + 1, "invalid hunk header: " + strings.TrimSuffix(lines[i], "\n")}
+	}
+	var hunk UnifiedHunk
+	hunk.Start1, hunk.Length1 = unifiedRange(m[1], m[2])
+	hunk.Start2, hunk.Length2 = unifiedRange(m[3], m[4])
+	count1, count2 := hunk.Length1, hunk.Length2
+	j := i + 1
+	for ; count1 > 0 || count2 > 0 || (j < len(lines) && strings.HasPrefix(lines[j], "\\")); j++ {
+		if j == len(lines) {
+			return UnifiedHunk{}, 0, &UnifiedError{j, fmt.Sprintf("hunk is missing %d old and %d new lines", count1, count2)}
+		}
+		line := lines[j]
+		if !strings.HasSuffix(line, "\n") {
+			line += "\n"
+		}
+		if line == "\n" {
+			// An empty context line with its leading space stripped.
+			line = " \n"
+		}
+		var op Op
+		switch line[0] {
+		case ' ':
+			op = OpEqual
+			count1--
+			count2--
+		case '-':
+			op = OpDelete
+			count1--
+		case '+':
+			op = OpInsert
+			count2--
+		case '\\':
+			// No newline at end of file.
+			if len(hunk.Diffs) == 0 {
+				return UnifiedHunk{}, 0, &UnifiedError{j + 1, "no newline marker before any line"}
+			}
+			last := &hunk.Diffs[len(hunk.Diffs)-1]
+			last.Text = strings.TrimSuffix(last.Text, "\n")
+			continue
+		default:
+			return UnifiedHunk{}, 0, &UnifiedError{j + 1, "unexpected line in hunk: " + strings.TrimSuffix(lines[j], "\n")}
+		}
+		if count1 < 0 || count2 < 0 {
+			return UnifiedHunk{}, 0, &UnifiedError{j + 1, "hunk has more lines than its header"}
+		}
+		if n := len(hunk.Diffs); n != 0 && hunk.Diffs[n-1].Op == op && strings.HasSuffix(hunk.Diffs[n-1].Text, "\n") {
+			hunk.Diffs[n-1].Text += line[1:]
+		} else {
+			hunk.Diffs = append(hunk.Diffs, Diff{op, line[1:]})
+		}
+	}
+	return hunk, j, nil
+}
+
+// unifiedRange converts the start and optional length of a hunk header range
+// to a 0-based line index and count.
+func unifiedRange(start, length string) (int, int) {
+	s, _ := strconv.Atoi(start)
+	if length == "" {
+		return s - 1, 1
+	}
+	n, _ := strconv.Atoi(length)
+	if n == 0 {
+		return s, 0
+	}
+	return s - 1, n
+}
+
+// unifiedName strips any timestamp from a "---" or "+++" file name and
+// unquotes git quoted names.
+func unifiedName(name string) string {
+	if i := strings.IndexByte(name, '\t'); i != -1 {
+		name = name[:i]
+	}
+	if strings.HasPrefix(name, `"`) {
+		if s, err := strconv.Unquote(name); err == nil {
+			return s
+		}
+	}
+	return name
+}
+
+// unifiedGitNames splits the names in a "diff --git" header.
+func unifiedGitNames(names string) (string, string) {
+	if strings.HasPrefix(names, `"`) {
+		for i := 1; i < len(names); i++ {
+			if names[i] == '\\' {
+				i++
+			} else if names[i] == '"' {
+				return unifiedName(names[:i+1]), unifiedName(strings.TrimPrefix(names[i+1:], " "))
+			}
+		}
+	}
+	if i := strings.Index(names, " b/"); strings.HasPrefix(names, "a/") && i != -1 {
+		return names[:i], unifiedName(names[i+1:])
+	}
+	if i := strings.IndexByte(names, ' '); i != -1 {
+		return names[:i], unifiedName(names[i+1:])
+	}
+	return names, names
+}
+
+// PatchFromUnified converts the hunks of a file in a unified diff to a list of
+// patches, with character offsets computed against text1, the original
+// contents of the file.  As with PatchMake, context is added around each
+// patch so that it can be applied with PatchApply.
+func (config *Config) PatchFromUnified(text1 string, file UnifiedFile) []Patch {
+	// Character offset of the start of each line of text1.
+	offsets := []int{0}
+	for i := 0; i < len(text1); i++ {
+		if text1[i] == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	patches := []Patch{}
+	// Like PatchMake, walk text1 as it is rolled forward by each patch, with
+	// shift tracking the difference between the two.
+	text := text1
+	shift := 0
+	for _, h := range file.Hunks {
+		p := Patch{Diffs: append([]Diff(nil), h.Diffs...)}
+		p.Start1 = offsets[max(0, min(h.Start1, len(offsets)-1))] + shift
+		p.Start2 = p.Start1
+		p.Length1 = len(config.DiffText1(p.Diffs))
+		p.Length2 = len(config.DiffText2(p.Diffs))
+		shift += p.Length2 - p.Length1
+		if p.Start2+p.Length1 > len(text) {
+			// The hunk runs past the end of text1.
+			patches = append(patches, p)
+			continue
+		}
+		start, length := p.Start2, p.Length1
+		p = config.PatchAddContext(p, text)
+		// Merge the added context with the hunk's own context lines.
+		if len(p.Diffs) > 1 && p.Diffs[0].Op == OpEqual && p.Diffs[1].Op == OpEqual {
+			p.Diffs = append([]Diff{{OpEqual, p.Diffs[0].Text + p.Diffs[1].Text}}, p.Diffs[2:]...)
+		}
+		if n := len(p.Diffs); n > 1 && p.Diffs[n-1].Op == OpEqual && p.Diffs[n-2].Op == OpEqual {
+			p.Diffs[n-2].Text += p.Diffs[n-1].Text
+			p.Diffs = p.Diffs[:n-1]
+		}
+		patches = append(patches, p)
+		text = text[:start] + config.DiffText2(h.Diffs) + text[start+length:]
+	}
+	return patches
+}
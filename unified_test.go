@@ -0,0 +1,230 @@
+package diffmatchpatch
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffUnified(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Text1    string
+		Text2    string
+		Context  int
+		Expected string
+	}{
+		{
+			"Equal",
+			"alpha\nbeta\n",
+			"alpha\nbeta\n",
+			3,
+			"",
+		},
+		{
+			"Insert into empty",
+			"",
+			"alpha\nbeta\n",
+			3,
+			"--- a\n+++ b\n@@ -0,0 +1,2 @@\n+alpha\n+beta\n",
+		},
+		{
+			"Delete all",
+			"alpha\n",
+			"",
+			3,
+			"--- a\n+++ b\n@@ -1 +0,0 @@\n-alpha\n",
+		},
+		{
+			"Separate hunks",
+			"one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten",
+			"one\n2\nthree\nfour\nfive\nsix\nseven\neight\nnine\n10",
+			3,
+			"--- a\n+++ b\n@@ -1,5 +1,5 @@\n one\n-two\n+2\n three\n four\n five\n@@ -7,4 +7,4 @@\n seven\n eight\n nine\n-ten\n\\ No newline at end of file\n+10\n\\ No newline at end of file\n",
+		},
+		{
+			"Merged hunks",
+			"one\ntwo\nthree\nfour\nfive\nsix\nseven\n",
+			"one\n2\nthree\nfour\nfive\nsix\n7\n",
+			2,
+			"--- a\n+++ b\n@@ -1,7 +1,7 @@\n one\n-two\n+2\n three\n four\n five\n six\n-seven\n+7\n",
+		},
+		{
+			"No context",
+			"one\ntwo\nthree\n",
+			"one\n2\nthree\n",
+			0,
+			"--- a\n+++ b\n@@ -2 +2 @@\n-two\n+2\n",
+		},
+		{
+			"Add newline at end of file",
+			"one\ntwo",
+			"one\ntwo\n",
+			3,
+			"--- a\n+++ b\n@@ -1,2 +1,2 @@\n one\n-two\n\\ No newline at end of file\n+two\n",
+		},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		config.UnifiedContext = test.Context
+		actual := config.DiffUnified("a", "b", test.Text1, test.Text2)
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
+
+func TestDiffToUnified(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Diffs    []Diff
+		Expected string
+	}{
+		{
+			"Line diffs",
+			[]Diff{
+				{OpEqual, "alpha\n"},
+				{OpDelete, "beta\n"},
+				{OpInsert, "gamma\n"},
+			},
+			"--- a\n+++ b\n@@ -1,2 +1,2 @@\n alpha\n-beta\n+gamma\n",
+		},
+		{
+			"Character diffs",
+			[]Diff{
+				{OpEqual, "alpha\nb"},
+				{OpDelete, "et"},
+				{OpInsert, "ell"},
+				{OpEqual, "a\ngamma\n"},
+			},
+			"--- a\n+++ b\n@@ -1,3 +1,3 @@\n alpha\n-beta\n+bella\n gamma\n",
+		},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		actual := config.DiffToUnified("a", "b", test.Diffs)
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
+
+func TestPatchToUnified(t *testing.T) {
+	config := NewDefaultConfig()
+	text1 := "The quick brown fox\njumps over\nthe lazy dog.\n"
+	text2 := "That quick brown fox\njumped over\nthe lazy dog.\n"
+	patches := config.PatchMake(text1, text2)
+	actual, err := config.PatchToUnified("a/fox.txt", "b/fox.txt", text1, patches)
+	assert.Nil(t, err)
+	assert.Equal(t, "--- a/fox.txt\n+++ b/fox.txt\n@@ -1,3 +1,3 @@\n-The quick brown fox\n-jumps over\n+That quick brown fox\n+jumped over\n the lazy dog.\n", actual)
+	// Patches that don't apply to the text.
+	_, err = config.PatchToUnified("a/fox.txt", "b/fox.txt", "The slow brown fox\n", patches)
+	assert.EqualError(t, err, "patch 0 does not apply to text at 0")
+}
+
+func TestUnifiedFromText(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Text     string
+		Expected []UnifiedFile
+	}{
+		{
+			"diff -u",
+			"--- a.txt\t2020-01-01 00:00:00.000000000 +0000\n+++ b.txt\t2020-01-02 00:00:00.000000000 +0000\n@@ -1,2 +1,2 @@\n alpha\n-beta\n+gamma\n",
+			[]UnifiedFile{
+				{OldName: "a.txt", NewName: "b.txt", Hunks: []UnifiedHunk{
+					{0, 0, 2, 2, []Diff{{OpEqual, "alpha\n"}, {OpDelete, "beta\n"}, {OpInsert, "gamma\n"}}},
+				}},
+			},
+		},
+		{
+			"Multiple hunks and files",
+			"Commit message.\n--- a/one\n+++ b/one\n@@ -1 +1 @@\n-1\n+one\n@@ -10,0 +11,2 @@\n+eleven\n+twelve\n--- a/two\n+++ b/two\n@@ -2,3 +2,2 @@\n two\n-three\n\n",
+			[]UnifiedFile{
+				{OldName: "a/one", NewName: "b/one", Hunks: []UnifiedHunk{
+					{0, 0, 1, 1, []Diff{{OpDelete, "1\n"}, {OpInsert, "one\n"}}},
+					{10, 10, 0, 2, []Diff{{OpInsert, "eleven\ntwelve\n"}}},
+				}},
+				{OldName: "a/two", NewName: "b/two", Hunks: []UnifiedHunk{
+					{1, 1, 3, 2, []Diff{{OpEqual, "two\n"}, {OpDelete, "three\n"}, {OpEqual, "\n"}}},
+				}},
+			},
+		},
+		{
+			"No newline at end of file",
+			"--- a\n+++ b\n@@ -1,2 +1,2 @@\n one\n-two\n\\ No newline at end of file\n+two\n",
+			[]UnifiedFile{
+				{OldName: "a", NewName: "b", Hunks: []UnifiedHunk{
+					{0, 0, 2, 2, []Diff{{OpEqual, "one\n"}, {OpDelete, "two"}, {OpInsert, "two\n"}}},
+				}},
+			},
+		},
+		{
+			"git extended headers",
+			"diff --git a/old.go b/new.go\nsimilarity index 90%\nrename from old.go\nrename to new.go\nindex 1234567..89abcde\n--- a/old.go\n+++ b/new.go\n@@ -1 +1 @@\n-x\n+y\n" +
+				"diff --git a/run.sh b/run.sh\nold mode 100644\nnew mode 100755\n" +
+				"diff --git a/gone.txt b/gone.txt\ndeleted file mode 100644\nindex 1234567..0000000\n--- a/gone.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-bye\n" +
+				"diff --git a/img.png b/img.png\nnew file mode 100644\nindex 0000000..1234567\nBinary files /dev/null and b/img.png differ\n" +
+				"diff --git \"a/sp ace\" \"b/sp ace\"\nnew file mode 100644\n--- /dev/null\n+++ \"b/sp ace\"\n@@ -0,0 +1 @@\n+hi\n",
+			[]UnifiedFile{
+				{OldName: "a/old.go", NewName: "b/new.go", Rename: true, Hunks: []UnifiedHunk{
+					{0, 0, 1, 1, []Diff{{OpDelete, "x\n"}, {OpInsert, "y\n"}}},
+				}},
+				{OldName: "a/run.sh", NewName: "b/run.sh", OldMode: "100644", NewMode: "100755"},
+				{OldName: "a/gone.txt", NewName: "/dev/null", OldMode: "100644", Hunks: []UnifiedHunk{
+					{0, 0, 1, 0, []Diff{{OpDelete, "bye\n"}}},
+				}},
+				{OldName: "/dev/null", NewName: "b/img.png", NewMode: "100644", Binary: true},
+				{OldName: "/dev/null", NewName: "b/sp ace", NewMode: "100644", Hunks: []UnifiedHunk{
+					{0, 0, 0, 1, []Diff{{OpInsert, "hi\n"}}},
+				}},
+			},
+		},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		actual, err := config.UnifiedFromText(test.Text)
+		assert.Nil(t, err, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+}
+
+func TestUnifiedFromTextErrors(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Text     string
+		Expected string
+	}{
+		{"Hunk without file", "@@ -1 +1 @@\n-a\n+b\n", "unified diff line 1: hunk outside of a file"},
+		{"Bad header", "--- a\n+++ b\n@@ -1 +x @@\n", "unified diff line 3: invalid hunk header: @@ -1 +x @@"},
+		{"Bad line", "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n*b\n", "unified diff line 5: unexpected line in hunk: *b"},
+		{"Too many lines", "--- a\n+++ b\n@@ -1 +1 @@\n-a\n-b\n+c\n", "unified diff line 5: hunk has more lines than its header"},
+		{"Truncated", "--- a\n+++ b\n@@ -1,3 +1,3 @@\n a\n", "unified diff line 4: hunk is missing 2 old and 2 new lines"},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		_, err := config.UnifiedFromText(test.Text)
+		assert.EqualError(t, err, test.Expected, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+	_, err := config.UnifiedFromText("--- a\n+++ b\n@@ -1 +1 @@\n?\n")
+	if assert.IsType(t, &UnifiedError{}, err) {
+		assert.Equal(t, 4, err.(*UnifiedError).Line)
+	}
+}
+
+func TestPatchFromUnified(t *testing.T) {
+	config := NewDefaultConfig()
+	text1 := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	text2 := "one\n2\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\n"
+	files, err := config.UnifiedFromText(config.DiffUnified("a", "b", text1, text2))
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+	patches := config.PatchFromUnified(text1, files[0])
+	assert.Equal(t, "@@ -1,28 +1,26 @@\n one%0A\n-two%0A\n+2%0A\n three%0Afour%0Afive%0Asix%0A\n@@ -29,19 +29,26 @@\n ven%0Aeight%0Anine%0Aten%0A\n+eleven%0A\n", config.PatchToText(patches))
+	actual, applied := config.PatchApply(patches, text1)
+	assert.Equal(t, []bool{true, true}, applied)
+	assert.Equal(t, text2, actual)
+	// Offsets are computed against the original text, and the patches still
+	// apply when it has moved.
+	moved := "zero\n" + text1
+	actual, applied = config.PatchApply(patches, moved)
+	assert.Equal(t, []bool{true, true}, applied)
+	assert.Equal(t, "zero\n"+text2, actual)
+}
@@ -1,7 +1,7 @@
 package diffmatchpatch
 
 import (
-	"strconv"
+	"net/url"
 	"strings"
 	"unicode/utf8"
 )
@@ -23,6 +23,12 @@ var unescaper = strings.NewReplacer(
 	"%23", "#", "%2A", "*",
 )
 
+// encodeURI escapes text with %xx notation, leaving spaces and the chars
+// unescaped by unescaper as they are.
+func encodeURI(text string) string {
+	return unescaper.Replace(strings.Replace(url.QueryEscape(text), "+", " ", -1))
+}
+
 // indexOf returns the first index of pattern in s, starting at s[i].
 func indexOf(s string, pattern string, i int) int {
 	if i > len(s)-1 {
@@ -65,6 +71,18 @@ func runesIndexOf(target, pattern []rune, i int) int {
 	return ind + i
 }
 
+// runesLastIndexOf returns the last index of pattern in target, starting at
+// target[i].
+func runesLastIndexOf(target, pattern []rune, i int) int {
+	if i < 0 {
+		return -1
+	}
+	if i >= len(target) {
+		return runesLastIndex(target, pattern)
+	}
+	return runesLastIndex(target[:i+1], pattern)
+}
+
 func runesEqual(r1, r2 []rune) bool {
 	if len(r1) != len(r2) {
 		return false
@@ -88,18 +106,32 @@ func runesIndex(r1, r2 []rune) int {
 	return -1
 }
 
-func intArrayToString(ns []uint32) string {
-	if len(ns) == 0 {
-		return ""
+// runesLastIndex is the equivalent of strings.LastIndex for rune slices.
+func runesLastIndex(r1, r2 []rune) int {
+	for i := len(r1) - len(r2); i >= 0; i-- {
+		if runesEqual(r1[i:i+len(r2)], r2) {
+			return i
+		}
+	}
+	return -1
+}
+
+// runeStart moves the byte offset i in s back to the start of the rune
+// containing it.
+func runeStart(s string, i int) int {
+	for i > 0 && i < len(s) && !utf8.RuneStart(s[i]) {
+		i--
 	}
-	// Appr. 3 chars per num plus the comma.
-	b := []byte{}
-	for _, n := range ns {
-		b = strconv.AppendInt(b, int64(n), 10)
-		b = append(b, ',')
+	return i
+}
+
+// runeEnd moves the byte offset i in s forward to the start of the next rune
+// when it falls inside a multi-byte rune.
+func runeEnd(s string, i int) int {
+	for i > 0 && i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
 	}
-	b = b[:len(b)-1]
-	return string(b)
+	return i
 }
 
 func min(x, y int) int {
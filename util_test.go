@@ -103,6 +103,36 @@ func TestLastIndexOf(t *testing.T) {
 	}
 }
 
+func TestRunesLastIndexOf(t *testing.T) {
+	tests := []struct {
+		String   string
+		Pattern  string
+		Position int
+		Expected int
+	}{
+		{"hi world", "world", -1, -1},
+		{"hi world", "world", 0, -1},
+		{"hi world", "world", 2, -1},
+		{"hi world", "world", 3, -1},
+		{"hi world", "world", 7, 3},
+		{"hi world", "world", 8, 3},
+		{"abbc", "b", -1, -1},
+		{"abbc", "b", 0, -1},
+		{"abbc", "b", 1, 1},
+		{"abbc", "b", 2, 2},
+		{"abbc", "b", 3, 2},
+		{"abbc", "b", 4, 2},
+		{"a\u03b2\u03b2c", "\u03b2", 0, -1},
+		{"a\u03b2\u03b2c", "\u03b2", 1, 1},
+		{"a\u03b2\u03b2c", "\u03b2", 2, 2},
+		{"a\u03b2\u03b2c", "\u03b2", 4, 2},
+	}
+	for i, test := range tests {
+		actual := runesLastIndexOf([]rune(test.String), []rune(test.Pattern), test.Position)
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %#v", i, test))
+	}
+}
+
 func speedtestTexts() (s1 string, s2 string) {
 	d1, err := ioutil.ReadFile(filepath.Join("testdata", "speedtest1.txt"))
 	if err != nil {
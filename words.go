@@ -0,0 +1,109 @@
+package diffmatchpatch
+
+import (
+	"context"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WordBoundary reports whether a word boundary falls between the adjacent
+// runes r1 and r2 of a text.
+type WordBoundary func(r1, r2 rune) bool
+
+// Word classes used by DefaultWordBoundary.
+const (
+	wordLetter = iota
+	wordSpace
+	wordOther
+)
+
+// DefaultWordBoundary splits a text into runs of letters, digits and
+// underscores, runs of white space, and single runes of any other kind
+// (punctuation, symbols, and ideographs from scripts written without
+// spaces).  Combining marks stay with the rune before them.
+func DefaultWordBoundary(r1, r2 rune) bool {
+	if unicode.IsMark(r2) {
+		return false
+	}
+	c1, c2 := wordClass(r1), wordClass(r2)
+	return c1 != c2 || c1 == wordOther
+}
+
+// wordClass returns the word class of r.
+func wordClass(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+		return wordOther
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_':
+		return wordLetter
+	case unicode.IsSpace(r):
+		return wordSpace
+	}
+	return wordOther
+}
+
+// DiffWords finds the differences between two texts word by word, using
+// DiffWordBoundary to split them into words.  Each diff holds whole words.
+func (config *Config) DiffWords(text1, text2 string) []Diff {
+	diffs, _ := config.DiffWordsContext(context.Background(), text1, text2)
+	return diffs
+}
+
+// DiffWordsContext finds the differences between two texts word by word,
+// stopping early and returning ctx.Err() when ctx is done.
+func (config *Config) DiffWordsContext(ctx context.Context, text1, text2 string) ([]Diff, error) {
+	boundary := config.DiffWordBoundary
+	if boundary == nil {
+		boundary = DefaultWordBoundary
+	}
+	runes1, runes2, words := wordsToRunes(text1, text2, boundary)
+	diffs := runesToLines(config.diffLines(ctx, runes1, runes2, config.diffDeadline()), words)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return diffs, nil
+}
+
+// wordsToRunes splits two texts into words, and reduces the texts to rune
+// slices where each rune represents one word, in the same way as
+// linesToRunes.  Returns the rune slices and the words, indexed by rune
+// value.
+func wordsToRunes(text1, text2 string, boundary WordBoundary) ([]rune, []rune, []string) {
+	// '\x00' is a valid character, but various debuggers don't like it. So
+	// we'll insert a junk entry to avoid generating a null character.
+	words := []string{""}
+	wordHash := map[string]rune{}
+	return wordsToRunesMunge(text1, boundary, &words, wordHash), wordsToRunesMunge(text2, boundary, &words, wordHash), words
+}
+
+// wordsToRunesMunge splits a text into words, and reduces the text to a rune
+// slice where each rune represents one word.
+func wordsToRunesMunge(text string, boundary WordBoundary, words *[]string, wordHash map[string]rune) []rune {
+	var runes []rune
+	for len(text) != 0 {
+		r1, i := utf8.DecodeRuneInString(text)
+		for i < len(text) {
+			r2, n := utf8.DecodeRuneInString(text[i:])
+			if boundary(r1, r2) {
+				break
+			}
+			r1 = r2
+			i += n
+		}
+		if len(*words) >= maxLineIndex-1 {
+			// Out of runes (keeping one for the rest of each text): use the
+			// rest of the text.
+			i = len(text)
+		}
+		word := text[:i]
+		text = text[i:]
+		r, ok := wordHash[word]
+		if !ok {
+			r = lineRune(len(*words))
+			*words = append(*words, word)
+			wordHash[word] = r
+		}
+		runes = append(runes, r)
+	}
+	return runes
+}
@@ -0,0 +1,93 @@
+package diffmatchpatch
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWordsToRunes(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Text     string
+		Expected []string
+	}{
+		{"Empty", "", nil},
+		{"Words and spaces", "The quick  brown fox", []string{"The", " ", "quick", "  ", "brown", " ", "fox"}},
+		{"Punctuation", "Hello, world!", []string{"Hello", ",", " ", "world", "!"}},
+		{"Numbers and underscores", "x_1 = 42.5", []string{"x_1", " ", "=", " ", "42", ".", "5"}},
+		{"Non-ASCII letters", "Größe ändern", []string{"Größe", " ", "ändern"}},
+		{"Combining marks", "café noir", []string{"café", " ", "noir"}},
+		{"Ideographs", "日本語です", []string{"日", "本", "語", "で", "す"}},
+		{"Newlines", "one\ntwo\n", []string{"one", "\n", "two", "\n"}},
+	}
+	for i, test := range tests {
+		runes, _, words := wordsToRunes(test.Text, "", DefaultWordBoundary)
+		var actual []string
+		for _, r := range runes {
+			actual = append(actual, words[lineIndex(r)])
+		}
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+	// Words shared by both texts get the same rune.
+	runes1, runes2, words := wordsToRunes("a b", "b a", DefaultWordBoundary)
+	assert.Equal(t, []rune{1, 2, 3}, runes1)
+	assert.Equal(t, []rune{3, 2, 1}, runes2)
+	assert.Equal(t, []string{"", "a", " ", "b"}, words)
+}
+
+func TestDiffWords(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Text1    string
+		Text2    string
+		Expected []Diff
+	}{
+		{
+			"Equal",
+			"The quick brown fox.",
+			"The quick brown fox.",
+			[]Diff{{OpEqual, "The quick brown fox."}},
+		},
+		{
+			"Changed word",
+			"The quick brown fox.",
+			"The quack brown fox.",
+			[]Diff{{OpEqual, "The "}, {OpDelete, "quick"}, {OpInsert, "quack"}, {OpEqual, " brown fox."}},
+		},
+		{
+			"Inserted words",
+			"The fox jumps.",
+			"The brown fox jumps high.",
+			[]Diff{{OpEqual, "The "}, {OpInsert, "brown "}, {OpEqual, "fox jumps"}, {OpInsert, " high"}, {OpEqual, "."}},
+		},
+		{
+			"Unicode",
+			"Die Größe ändern",
+			"Die Breite ändern",
+			[]Diff{{OpEqual, "Die "}, {OpDelete, "Größe"}, {OpInsert, "Breite"}, {OpEqual, " ändern"}},
+		},
+	}
+	config := NewDefaultConfig()
+	for i, test := range tests {
+		actual := config.DiffWords(test.Text1, test.Text2)
+		assert.Equal(t, test.Expected, actual, fmt.Sprintf("Test case #%d, %s", i, test.Name))
+	}
+	// Custom word boundaries: split on white space only.
+	config.DiffWordBoundary = func(r1, r2 rune) bool {
+		return unicode.IsSpace(r1) != unicode.IsSpace(r2)
+	}
+	assert.Equal(t, []Diff{
+		{OpEqual, "Hello "},
+		{OpDelete, "world!"},
+		{OpInsert, "world?"},
+	}, config.DiffWords("Hello world!", "Hello world?"))
+	// Canceled context.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := config.DiffWordsContext(ctx, "a", "b")
+	assert.Equal(t, context.Canceled, err)
+}